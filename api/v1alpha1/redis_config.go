@@ -0,0 +1,76 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ManagedConfigKeys are the redis.conf directives the operator renders itself and that spec.config must not set.
+// The list is mirrored by the validation rule on RedisSpec.Config.
+var ManagedConfigKeys = []string{
+	"port",
+	"requirepass",
+	"masterauth",
+	"dir",
+	"replicaof",
+	"slaveof",
+	"replica-announce-ip",
+	"cluster-enabled",
+	"cluster-config-file",
+	"cluster-announce-hostname",
+	"cluster-preferred-endpoint-type",
+	"tls-port",
+	"tls-cert-file",
+	"tls-key-file",
+	"tls-ca-cert-file",
+	"tls-auth-clients",
+	"tls-replication",
+	"tls-cluster",
+	"include",
+}
+
+// ManagedConfigKeysIn returns the keys of a config map that the operator manages, sorted.
+// Redis treats directive names case-insensitively, so the comparison does too.
+func ManagedConfigKeysIn(config map[string]string) []string {
+	var managed []string
+	for key := range config {
+		if slices.Contains(ManagedConfigKeys, strings.ToLower(key)) {
+			managed = append(managed, key)
+		}
+	}
+	sort.Strings(managed)
+	return managed
+}
+
+// MalformedConfigKeysIn returns the keys of a config map that are empty or contain whitespace, or whose value
+// contains a line break, sorted. Rendered into redis.conf, they would inject further directives.
+// The check is mirrored by the validation rules on RedisSpec.Config.
+func MalformedConfigKeysIn(config map[string]string) []string {
+	var malformed []string
+	for key, value := range config {
+		if key == "" || strings.ContainsFunc(key, unicode.IsSpace) || strings.ContainsAny(value, "\r\n") {
+			malformed = append(malformed, strconv.Quote(key))
+		}
+	}
+	sort.Strings(malformed)
+	return malformed
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/pehlicd/redis-operator/api/v1beta1"
+)
+
+const (
+	// DefaultPasswordSecretKey is the key of the password in the password secret.
+	DefaultPasswordSecretKey = "password"
+	// PasswordSecretKeyAnnotation carries spec.passwordSecret.key of v1beta1 objects using another key than
+	// DefaultPasswordSecretKey, which v1alpha1 has no field for.
+	PasswordSecretKeyAnnotation = "redis.yazio.com/password-secret-key"
+)
+
+// PasswordSecretKey returns the key of the password in the password secret of the Redis.
+func PasswordSecretKey(redis *Redis) string {
+	if key := redis.Annotations[PasswordSecretKeyAnnotation]; key != "" {
+		return key
+	}
+	return DefaultPasswordSecretKey
+}
+
+// ConvertTo converts this Redis (v1alpha1) to the Hub version (v1beta1).
+func (src *Redis) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.Redis)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	// The key is a field of v1beta1, so it must not linger as an annotation.
+	delete(dst.Annotations, PasswordSecretKeyAnnotation)
+	if len(dst.Annotations) == 0 {
+		dst.Annotations = nil
+	}
+
+	spec := &src.Spec
+	dst.Spec = v1beta1.RedisSpec{
+		Image:            spec.Image,
+		Replicas:         spec.Replicas,
+		WorkloadKind:     v1beta1.WorkloadKind(spec.WorkloadKind),
+		Mode:             v1beta1.Mode(spec.Mode),
+		DeletionPolicy:   v1beta1.DeletionPolicy(spec.DeletionPolicy),
+		Port:             int32Value(spec.Port),
+		PasswordSecret:   v1beta1.PasswordSecret{Name: spec.PasswordSecretName, Key: PasswordSecretKey(src)},
+		PasswordRotation: (*v1beta1.PasswordRotation)(spec.PasswordRotation),
+		Service: v1beta1.Service{
+			Name: spec.Service.Name,
+			Type: corev1.ServiceType(spec.Service.Type),
+			Port: int32Value(spec.Service.Port),
+		},
+		Resources:      spec.Resources,
+		ReadinessProbe: spec.ReadinessProbe,
+		LivenessProbe:  spec.LivenessProbe,
+		Config:         spec.Config,
+		Sentinel:       (*v1beta1.Sentinel)(spec.Sentinel),
+		Cluster:        (*v1beta1.Cluster)(spec.Cluster),
+		TLS:            (*v1beta1.TLS)(spec.TLS),
+		Autoscaling:    (*v1beta1.Autoscaling)(spec.Autoscaling),
+	}
+	if spec.Env != nil {
+		dst.Spec.Env = *spec.Env
+	}
+	if readService := spec.Service.ReadService; readService != nil {
+		dst.Spec.Service.ReadService = &v1beta1.ReadService{
+			Name: readService.Name,
+			Type: corev1.ServiceType(readService.Type),
+			Port: int32Value(readService.Port),
+		}
+	}
+	if backup := spec.Backup; backup != nil {
+		dst.Spec.Backup = &v1beta1.ScheduledBackup{
+			Schedule:  backup.Schedule,
+			Suspend:   backup.Suspend,
+			Retention: v1beta1.BackupRetention(backup.Retention),
+			Destination: v1beta1.BackupDestination{
+				S3:  (*v1beta1.S3Destination)(backup.Destination.S3),
+				PVC: (*v1beta1.PVCDestination)(backup.Destination.PVC),
+			},
+		}
+	}
+	if restore := spec.Restore; restore != nil {
+		dst.Spec.Restore = &v1beta1.Restore{
+			BackupName: restore.BackupName,
+			URL:        restore.URL,
+			S3:         (*v1beta1.S3Source)(restore.S3),
+			Checksum:   restore.Checksum,
+		}
+	}
+	if monitoring := spec.Monitoring; monitoring != nil {
+		dst.Spec.Monitoring = &v1beta1.Monitoring{Exporter: (*v1beta1.Exporter)(monitoring.Exporter)}
+	}
+	if persistence := spec.Persistence; persistence != nil {
+		dst.Spec.Persistence = &v1beta1.Persistence{
+			StorageClassName: persistence.StorageClassName,
+			Size:             persistence.Size,
+			AccessModes:      persistence.AccessModes,
+			RDB:              (*v1beta1.RDB)(persistence.RDB),
+		}
+		if aof := persistence.AOF; aof != nil {
+			dst.Spec.Persistence.AOF = &v1beta1.AOF{Enabled: aof.Enabled, AppendFsync: v1beta1.AppendFsync(aof.AppendFsync)}
+		}
+	}
+
+	status := &src.Status
+	dst.Status = v1beta1.RedisStatus{
+		PasswordSecretName:        status.PasswordSecretName,
+		Phase:                     v1beta1.Phase(status.Phase),
+		ObservedGeneration:        status.ObservedGeneration,
+		Version:                   status.Version,
+		Replicas:                  status.Replicas,
+		ReadyReplicas:             status.ReadyReplicas,
+		Selector:                  status.Selector,
+		Primary:                   status.Primary,
+		PrimaryEndpoint:           status.PrimaryEndpoint,
+		ReadEndpoint:              status.ReadEndpoint,
+		LastPasswordRotationTime:  status.LastPasswordRotationTime,
+		PasswordRotationStartTime: status.PasswordRotationStartTime,
+		LastBackupTime:            status.LastBackupTime,
+		TLSCertificateNotAfter:    status.TLSCertificateNotAfter,
+		Conditions:                status.Conditions,
+	}
+	for _, pod := range status.Pods {
+		dst.Status.Pods = append(dst.Status.Pods, v1beta1.RedisPodStatus(pod))
+	}
+	if cluster := status.Cluster; cluster != nil {
+		dst.Status.Cluster = &v1beta1.ClusterStatus{
+			State:         cluster.State,
+			AssignedSlots: cluster.AssignedSlots,
+			Shards:        cluster.Shards,
+		}
+		for _, node := range cluster.Nodes {
+			dst.Status.Cluster.Nodes = append(dst.Status.Cluster.Nodes, v1beta1.ClusterNodeStatus(node))
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the Hub version (v1beta1) to this Redis (v1alpha1).
+func (dst *Redis) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.Redis)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	// Keep a key other than the default as an annotation, so converting back restores it.
+	if key := src.Spec.PasswordSecret.Key; key != "" && key != DefaultPasswordSecretKey {
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[PasswordSecretKeyAnnotation] = key
+	} else {
+		delete(dst.Annotations, PasswordSecretKeyAnnotation)
+	}
+
+	spec := &src.Spec
+	dst.Spec = RedisSpec{
+		Image:              spec.Image,
+		Replicas:           spec.Replicas,
+		WorkloadKind:       WorkloadKind(spec.WorkloadKind),
+		Mode:               Mode(spec.Mode),
+		DeletionPolicy:     DeletionPolicy(spec.DeletionPolicy),
+		Port:               int32Ptr(spec.Port),
+		PasswordSecretName: spec.PasswordSecret.Name,
+		PasswordRotation:   (*PasswordRotation)(spec.PasswordRotation),
+		Service: Service{
+			Name: spec.Service.Name,
+			Type: string(spec.Service.Type),
+			Port: int32Ptr(spec.Service.Port),
+		},
+		Resources:      spec.Resources,
+		ReadinessProbe: spec.ReadinessProbe,
+		LivenessProbe:  spec.LivenessProbe,
+		Config:         spec.Config,
+		Sentinel:       (*Sentinel)(spec.Sentinel),
+		Cluster:        (*Cluster)(spec.Cluster),
+		TLS:            (*TLS)(spec.TLS),
+		Autoscaling:    (*Autoscaling)(spec.Autoscaling),
+	}
+	if spec.Env != nil {
+		env := spec.Env
+		dst.Spec.Env = &env
+	}
+	if readService := spec.Service.ReadService; readService != nil {
+		dst.Spec.Service.ReadService = &ReadService{
+			Name: readService.Name,
+			Type: string(readService.Type),
+			Port: int32Ptr(readService.Port),
+		}
+	}
+	if backup := spec.Backup; backup != nil {
+		dst.Spec.Backup = &ScheduledBackup{
+			Schedule:  backup.Schedule,
+			Suspend:   backup.Suspend,
+			Retention: BackupRetention(backup.Retention),
+			Destination: BackupDestination{
+				S3:  (*S3Destination)(backup.Destination.S3),
+				PVC: (*PVCDestination)(backup.Destination.PVC),
+			},
+		}
+	}
+	if restore := spec.Restore; restore != nil {
+		dst.Spec.Restore = &Restore{
+			BackupName: restore.BackupName,
+			URL:        restore.URL,
+			S3:         (*S3Source)(restore.S3),
+			Checksum:   restore.Checksum,
+		}
+	}
+	if monitoring := spec.Monitoring; monitoring != nil {
+		dst.Spec.Monitoring = &Monitoring{Exporter: (*Exporter)(monitoring.Exporter)}
+	}
+	if persistence := spec.Persistence; persistence != nil {
+		dst.Spec.Persistence = &Persistence{
+			StorageClassName: persistence.StorageClassName,
+			Size:             persistence.Size,
+			AccessModes:      persistence.AccessModes,
+			RDB:              (*RDB)(persistence.RDB),
+		}
+		if aof := persistence.AOF; aof != nil {
+			dst.Spec.Persistence.AOF = &AOF{Enabled: aof.Enabled, AppendFsync: AppendFsync(aof.AppendFsync)}
+		}
+	}
+
+	status := &src.Status
+	dst.Status = RedisStatus{
+		PasswordSecretName:        status.PasswordSecretName,
+		Phase:                     Phase(status.Phase),
+		ObservedGeneration:        status.ObservedGeneration,
+		Version:                   status.Version,
+		Replicas:                  status.Replicas,
+		ReadyReplicas:             status.ReadyReplicas,
+		Selector:                  status.Selector,
+		Primary:                   status.Primary,
+		PrimaryEndpoint:           status.PrimaryEndpoint,
+		ReadEndpoint:              status.ReadEndpoint,
+		LastPasswordRotationTime:  status.LastPasswordRotationTime,
+		PasswordRotationStartTime: status.PasswordRotationStartTime,
+		LastBackupTime:            status.LastBackupTime,
+		TLSCertificateNotAfter:    status.TLSCertificateNotAfter,
+		Conditions:                status.Conditions,
+	}
+	for _, pod := range status.Pods {
+		dst.Status.Pods = append(dst.Status.Pods, RedisPodStatus(pod))
+	}
+	if cluster := status.Cluster; cluster != nil {
+		dst.Status.Cluster = &ClusterStatus{
+			State:         cluster.State,
+			AssignedSlots: cluster.AssignedSlots,
+			Shards:        cluster.Shards,
+		}
+		for _, node := range cluster.Nodes {
+			dst.Status.Cluster.Nodes = append(dst.Status.Cluster.Nodes, ClusterNodeStatus(node))
+		}
+	}
+
+	return nil
+}
+
+// int32Value returns the value of an optional port, or 0 when it is unset.
+func int32Value(value *int32) int32 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// int32Ptr returns a pointer to a port, or nil when it is unset, so unset ports stay unset.
+func int32Ptr(value int32) *int32 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// DefaultImageTag is the tag images without a tag or digest are resolved to.
+	// It is mirrored by the default of RedisSpec.Image.
+	DefaultImageTag = "7.4"
+
+	// TLSMountPath is the directory the TLS secret is projected to in the Redis and Sentinel pods.
+	TLSMountPath = "/etc/redis-tls"
+	// TLSCACertKey is the secret key holding the CA certificate, next to tls.crt and tls.key.
+	TLSCACertKey = "ca.crt"
+	// PasswordMountPath is the directory the password secret is projected to when the password is rotated.
+	// Unlike environment variables, projected secrets follow updates of the secret without a restart.
+	PasswordMountPath = "/etc/redis-auth"
+)
+
+// SetDefaults fills in the fields the operator would otherwise default when rendering the pods,
+// so the stored object shows what actually runs. Fields that are already set are kept.
+func SetDefaults(redis *Redis) {
+	if redis.Spec.ReadinessProbe == nil {
+		redis.Spec.ReadinessProbe = DefaultReadinessProbe(redis)
+	}
+	if redis.Spec.LivenessProbe == nil {
+		redis.Spec.LivenessProbe = DefaultLivenessProbe(redis)
+	}
+	if len(redis.Spec.Resources.Requests) == 0 && len(redis.Spec.Resources.Limits) == 0 {
+		redis.Spec.Resources = DefaultResources()
+	}
+	if sentinel := redis.Spec.Sentinel; sentinel != nil &&
+		len(sentinel.Resources.Requests) == 0 && len(sentinel.Resources.Limits) == 0 {
+		sentinel.Resources = DefaultSentinelResources()
+	}
+}
+
+// ImageWithTag returns the image with DefaultImageTag appended when it has neither a tag nor a digest.
+func ImageWithTag(image string) string {
+	if image == "" || strings.Contains(image, "@") {
+		return image
+	}
+	// A colon before the last slash separates a registry port, not a tag.
+	if strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+		return image
+	}
+	return image + ":" + DefaultImageTag
+}
+
+// DefaultReadinessProbe returns the readiness probe of the Redis container when none is set.
+// It pings the server with redis-cli, authenticating with the mounted password while it is rotated.
+func DefaultReadinessProbe(redis *Redis) *corev1.Probe {
+	if redis.Spec.PasswordRotation != nil && redis.Spec.Port != nil {
+		return PasswordProbe(redis, *redis.Spec.Port)
+	}
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{
+				Command: append(RedisCLI(redis), "ping"),
+			},
+		},
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       10,
+		FailureThreshold:    3,
+	}
+}
+
+// DefaultLivenessProbe returns the liveness probe of the Redis container when none is set.
+func DefaultLivenessProbe(redis *Redis) *corev1.Probe {
+	port := int32(6379)
+	if redis.Spec.Port != nil {
+		port = *redis.Spec.Port
+	}
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(port)},
+		},
+		InitialDelaySeconds: 15,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       20,
+		FailureThreshold:    3,
+	}
+}
+
+// PasswordProbe returns a readiness probe pinging the server on the given port with the password read from the
+// projected password secret, so it keeps passing after the password was rotated.
+func PasswordProbe(redis *Redis, port int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: []string{"sh", "-c",
+				fmt.Sprintf(`REDISCLI_AUTH="$(cat %s/password)" %s -p %d ping`, PasswordMountPath, strings.Join(RedisCLI(redis), " "), port)}},
+		},
+		InitialDelaySeconds: 5,
+		TimeoutSeconds:      1,
+		PeriodSeconds:       10,
+		FailureThreshold:    3,
+	}
+}
+
+// RedisCLI returns the redis-cli command connecting to a server of the instance, including the TLS flags.
+func RedisCLI(redis *Redis) []string {
+	if redis.Spec.TLS == nil {
+		return []string{"redis-cli"}
+	}
+	args := []string{"redis-cli", "--tls", "--cacert", fmt.Sprintf("%s/%s", TLSMountPath, TLSCACertKey)}
+	if redis.Spec.TLS.AuthClients {
+		args = append(args,
+			"--cert", fmt.Sprintf("%s/%s", TLSMountPath, corev1.TLSCertKey),
+			"--key", fmt.Sprintf("%s/%s", TLSMountPath, corev1.TLSPrivateKeyKey),
+		)
+	}
+	return args
+}
+
+// DefaultResources returns the resource requirements of the Redis pods when none are set.
+// They are mirrored by the default of RedisSpec.Resources.
+func DefaultResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	}
+}
+
+// DefaultSentinelResources returns the resource requirements of the Sentinel pods when none are set.
+// They are mirrored by the default of Sentinel.Resources.
+func DefaultSentinelResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+	}
+}
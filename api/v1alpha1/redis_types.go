@@ -18,20 +18,67 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// WorkloadKind is the kind of workload that runs the Redis pods.
+// +kubebuilder:validation:Enum=Deployment;StatefulSet
+type WorkloadKind string
+
+const (
+	// WorkloadKindDeployment runs Redis pods in a Deployment.
+	WorkloadKindDeployment WorkloadKind = "Deployment"
+	// WorkloadKindStatefulSet runs Redis pods in a StatefulSet with stable names and network identities.
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+)
+
+// Mode is the topology of the Redis pods.
+// +kubebuilder:validation:Enum=standalone;replication;cluster
+type Mode string
+
+const (
+	// ModeStandalone runs independent Redis servers.
+	ModeStandalone Mode = "standalone"
+	// ModeReplication runs one primary and replicates it to every other pod.
+	ModeReplication Mode = "replication"
+	// ModeCluster runs a sharded Redis Cluster with a primary and replicas per shard.
+	ModeCluster Mode = "cluster"
+)
+
+// ExporterPort is reserved for the metrics exporter next to Redis, so the Redis and service ports must not use it.
+const ExporterPort = 9121
+
 // RedisSpec defines the desired state of Redis.
+// +kubebuilder:validation:XValidation:rule="!has(self.sentinel) || self.mode == 'replication'",message="sentinel requires replication mode"
+// +kubebuilder:validation:XValidation:rule="self.mode != 'cluster' || has(self.cluster)",message="cluster mode requires spec.cluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.autoscaling) || self.mode == 'replication'",message="autoscaling requires replication mode"
+// +kubebuilder:validation:XValidation:rule="!has(self.backup) || self.mode != 'cluster'",message="backups are not supported in cluster mode"
+// +kubebuilder:validation:XValidation:rule="!has(self.restore) || self.mode != 'cluster'",message="restore is not supported in cluster mode"
+// +kubebuilder:validation:XValidation:rule="!has(self.restore) || (has(oldSelf.restore) && self.restore == oldSelf.restore)",message="restore can only be set when the Redis is created"
+// +kubebuilder:validation:XValidation:rule="!has(self.deletionPolicy) || self.deletionPolicy != 'Snapshot' || has(self.backup)",message="the Snapshot deletion policy requires spec.backup"
 type RedisSpec struct {
 	// Image is the container image for the Redis instance.
 	// +kubebuilder:validation:Required
-	// +kubebuilder:default="bitnami/redis"
+	// +kubebuilder:default="bitnami/redis:7.4"
 	Image string `json:"image"`
 	// Replicas is the number of desired replicas.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:default=1
 	Replicas *int32 `json:"replicas,omitempty"`
+	// WorkloadKind is the kind of workload that runs the Redis pods.
+	// StatefulSet gives every pod a stable name and DNS entry through a headless service.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Deployment
+	WorkloadKind WorkloadKind `json:"workloadKind,omitempty"`
+	// Mode is the topology of the Redis pods. In replication mode the operator elects a primary,
+	// configures every other pod as its replica and points the service at the primary only.
+	// In cluster mode the slots are sharded across spec.cluster.shards primaries.
+	// Replication and cluster mode run the Redis pods in a StatefulSet regardless of WorkloadKind.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=standalone
+	Mode Mode `json:"mode,omitempty"`
 	// Port is the port on which Redis will listen.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Minimum=1
@@ -41,6 +88,10 @@ type RedisSpec struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:default="redis-password"
 	PasswordSecretName string `json:"passwordSecretName,omitempty"`
+	// PasswordRotation enables rotating the Redis password, either on an interval or when the Redis is
+	// annotated with redis.yazio.com/rotate-password.
+	// +kubebuilder:validation:Optional
+	PasswordRotation *PasswordRotation `json:"passwordRotation,omitempty"`
 	// Env is a list of environment variables to set in the Redis container.
 	// +kubebuilder:validation:Optional
 	Env *[]corev1.EnvVar `json:"env,omitempty"`
@@ -58,6 +109,312 @@ type RedisSpec struct {
 	// LivenessProbe is the probe to check if the Redis instance is alive.
 	// +kubebuilder:validation:Optional
 	LivenessProbe *corev1.Probe `json:"livenessProbe,omitempty"`
+	// Persistence defines the persistent storage for the Redis data directory.
+	// Enabling persistence runs the Redis pods in a StatefulSet regardless of WorkloadKind.
+	// +kubebuilder:validation:Optional
+	Persistence *Persistence `json:"persistence,omitempty"`
+	// Config holds redis.conf directives, such as maxmemory or maxmemory-policy, rendered into a config map
+	// mounted into the Redis pods. Directives the operator manages itself are rejected, and so are line breaks,
+	// which would smuggle further directives into redis.conf.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:XValidation:rule="self.all(k, !(k.lowerAscii() in ['port', 'requirepass', 'masterauth', 'dir', 'replicaof', 'slaveof', 'replica-announce-ip', 'cluster-enabled', 'cluster-config-file', 'cluster-announce-hostname', 'cluster-preferred-endpoint-type', 'tls-port', 'tls-cert-file', 'tls-key-file', 'tls-ca-cert-file', 'tls-auth-clients', 'tls-replication', 'tls-cluster', 'include']))",message="config must not set directives managed by the operator"
+	// +kubebuilder:validation:XValidation:rule=`self.all(k, k.matches('^\\S+$'))`,message="config keys must not be empty or contain whitespace"
+	// +kubebuilder:validation:XValidation:rule=`self.all(k, !self[k].matches('[\\r\\n]'))`,message="config values must not contain line breaks"
+	Config map[string]string `json:"config,omitempty"`
+	// Sentinel deploys Redis Sentinel to monitor the primary and fail over automatically.
+	// It requires replication mode.
+	// +kubebuilder:validation:Optional
+	Sentinel *Sentinel `json:"sentinel,omitempty"`
+	// Cluster defines the shard layout in cluster mode. The number of Redis pods is derived from it
+	// and Replicas is ignored.
+	// +kubebuilder:validation:Optional
+	Cluster *Cluster `json:"cluster,omitempty"`
+	// TLS serves client, replication, cluster bus and Sentinel traffic over TLS only.
+	// +kubebuilder:validation:Optional
+	TLS *TLS `json:"tls,omitempty"`
+	// Autoscaling scales the read replicas of a replicated instance with a HorizontalPodAutoscaler
+	// targeting the Redis. The autoscaler owns spec.replicas while it is set.
+	// +kubebuilder:validation:Optional
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+	// Backup takes RedisBackups of the instance on a cron schedule and prunes them by the retention policy.
+	// +kubebuilder:validation:Optional
+	Backup *ScheduledBackup `json:"backup,omitempty"`
+	// Restore bootstraps the instance from an RDB snapshot, fetched and verified by init containers before
+	// Redis starts. It can only be set when the Redis is created. Pods whose data directory already holds
+	// data skip the restore.
+	// +kubebuilder:validation:Optional
+	Restore *Restore `json:"restore,omitempty"`
+	// DeletionPolicy decides what happens to the data when the Redis is deleted. Delete removes the password
+	// Secret and the data volume claims with the instance, Retain keeps both, and Snapshot stores a final
+	// RedisBackup at spec.backup.destination before deleting them. The Redis is only removed once the policy
+	// has completed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Delete
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Monitoring runs a metrics exporter next to every Redis pod.
+	// +kubebuilder:validation:Optional
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+}
+
+// DeletionPolicy decides what happens to the data of a deleted Redis.
+// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the password Secret and the data volume claims.
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain orphans the password Secret and the data volume claims.
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicySnapshot takes a final backup and then deletes like DeletionPolicyDelete.
+	DeletionPolicySnapshot DeletionPolicy = "Snapshot"
+)
+
+// Monitoring defines how the metrics of the instance are exposed.
+type Monitoring struct {
+	// Exporter runs oliver006/redis_exporter as a sidecar of every Redis pod and exposes its metrics on the
+	// port named metrics of the primary service.
+	// +kubebuilder:validation:Optional
+	Exporter *Exporter `json:"exporter,omitempty"`
+}
+
+// Exporter defines the redis_exporter sidecar, which authenticates with the password secret. With
+// spec.passwordRotation it reads the password from a password file kept up to date by the operator.
+type Exporter struct {
+	// Enabled adds the sidecar to the Redis pods.
+	// +kubebuilder:validation:Optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Image is the redis_exporter image.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="oliver006/redis_exporter"
+	Image string `json:"image,omitempty"`
+	// Resources are the compute resources of the sidecar.
+	// +kubebuilder:validation:Optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// ExtraArgs are appended to the arguments of the exporter, for example --include-system-metrics.
+	// +kubebuilder:validation:Optional
+	ExtraArgs []string `json:"extraArgs,omitempty"`
+}
+
+// Restore defines the RDB snapshot a new Redis is bootstrapped from. Exactly one of BackupName and URL must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.backupName) != has(self.url)",message="exactly one of backupName or url must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.url) || !self.url.startsWith('s3://') || has(self.s3)",message="s3:// URLs require s3"
+type Restore struct {
+	// BackupName is the name of a completed RedisBackup in the same namespace whose snapshot is restored.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	BackupName string `json:"backupName,omitempty"`
+	// URL is the location of an RDB file: an http(s) URL, such as a presigned S3 URL, or s3://<bucket>/<key>
+	// fetched from the object store configured in S3.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^(https?|s3)://`
+	URL string `json:"url,omitempty"`
+	// S3 defines the object store s3:// URLs are fetched from.
+	// +kubebuilder:validation:Optional
+	S3 *S3Source `json:"s3,omitempty"`
+	// Checksum is the expected SHA-256 checksum of the RDB file, formatted as sha256:<hex>.
+	// The checksum recorded by the RedisBackup is verified for BackupName.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^sha256:[0-9a-f]{64}$`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// S3Source defines the S3-compatible object store an s3:// restore URL is fetched from.
+type S3Source struct {
+	// Endpoint is the URL of the object store, for example https://s3.eu-central-1.amazonaws.com
+	// or http://minio.minio.svc:9000.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+	// CredentialsSecretName is the name of a secret holding the access key ID under AWS_ACCESS_KEY_ID
+	// and the secret access key under AWS_SECRET_ACCESS_KEY.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	CredentialsSecretName string `json:"credentialsSecretName"`
+	// InsecureSkipVerify skips the verification of the endpoint's TLS certificate.
+	// +kubebuilder:validation:Optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// Image is the MinIO client image fetching the snapshot.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="minio/mc"
+	Image string `json:"image,omitempty"`
+}
+
+// ScheduledBackup defines RedisBackups taken on a schedule.
+type ScheduledBackup struct {
+	// Schedule is a cron expression with five fields, such as "0 3 * * *", or a descriptor such as @daily.
+	// It is evaluated in UTC.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// Suspend stops new backups from being taken. Existing backups are still pruned.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Retention selects the completed backups to keep. Without any rule every backup is kept.
+	// +kubebuilder:validation:Optional
+	Retention BackupRetention `json:"retention,omitempty"`
+	// Destination is where the RDB snapshots are stored.
+	// +kubebuilder:validation:Required
+	Destination BackupDestination `json:"destination"`
+}
+
+// BackupRetention defines which completed backups are kept. A backup is kept if any rule selects it;
+// the others are deleted together with their snapshot.
+type BackupRetention struct {
+	// KeepLast keeps the given number of most recent backups.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	KeepLast int32 `json:"keepLast,omitempty"`
+	// KeepDaily keeps the most recent backup of each of the given number of most recent days with a backup.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	KeepDaily int32 `json:"keepDaily,omitempty"`
+	// KeepWeekly keeps the most recent backup of each of the given number of most recent ISO weeks with a backup.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	KeepWeekly int32 `json:"keepWeekly,omitempty"`
+}
+
+// Autoscaling defines the HorizontalPodAutoscaler scaling the number of Redis pods, including the primary.
+// +kubebuilder:validation:XValidation:rule="self.minReplicas <= self.maxReplicas",message="minReplicas must not exceed maxReplicas"
+type Autoscaling struct {
+	// MinReplicas is the lower limit of Redis pods.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=2
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit of Redis pods.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the average CPU utilization of the Redis pods to scale at.
+	// Without any target the pods are scaled at 80% CPU utilization.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the average memory utilization of the Redis pods to scale at.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+}
+
+// TLS defines the certificates Redis serves TLS with.
+type TLS struct {
+	// SecretName is the name of a kubernetes.io/tls secret holding tls.crt, tls.key and ca.crt.
+	// The certificate must be valid for the service DNS names and, with AuthClients, for client authentication.
+	// Without it the operator generates a self-signed CA and a server certificate and renews it before expiry.
+	// +kubebuilder:validation:Optional
+	SecretName string `json:"secretName,omitempty"`
+	// AuthClients requires clients to present a certificate signed by the CA.
+	// +kubebuilder:validation:Optional
+	AuthClients bool `json:"authClients,omitempty"`
+}
+
+// Cluster defines the shard layout of a Redis Cluster.
+type Cluster struct {
+	// Shards is the number of primaries the hash slots are distributed across.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=3
+	// +kubebuilder:default=3
+	Shards int32 `json:"shards,omitempty"`
+	// ReplicasPerShard is the number of replicas of every primary.
+	// Pods are assigned to shards by ordinal, so it cannot be changed once set.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="replicasPerShard is immutable"
+	ReplicasPerShard int32 `json:"replicasPerShard,omitempty"`
+}
+
+// Sentinel defines the Redis Sentinel deployment monitoring a replicated Redis instance.
+// +kubebuilder:validation:XValidation:rule="self.quorum <= self.replicas",message="quorum must not exceed the number of sentinel replicas"
+type Sentinel struct {
+	// Replicas is the number of Sentinel pods.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Quorum is the number of Sentinels that need to agree the primary is down before failing over.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=2
+	Quorum int32 `json:"quorum,omitempty"`
+	// DownAfterMilliseconds is how long the primary must be unreachable before it is considered down.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5000
+	DownAfterMilliseconds int32 `json:"downAfterMilliseconds,omitempty"`
+	// FailoverTimeout is the failover timeout in milliseconds.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=60000
+	FailoverTimeout int32 `json:"failoverTimeout,omitempty"`
+	// Resources defines the resource requirements for the Sentinel pods.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={requests: {cpu: "50m", memory: "64Mi"}, limits: {cpu: "200m", memory: "128Mi"}}
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// PasswordRotation defines how the Redis password is rotated. The new password is accepted next to the old
+// one before the secret is updated, and the old password is dropped once the grace period has passed.
+type PasswordRotation struct {
+	// Interval is the time between two rotations, for example 2160h for 90 days.
+	// Without an interval the password is only rotated on request.
+	// +kubebuilder:validation:Optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// GracePeriod is how long the old password keeps being accepted after the secret has been updated,
+	// giving clients time to reload it.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="10m"
+	GracePeriod metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// Persistence defines the persistent storage configuration for Redis.
+type Persistence struct {
+	// StorageClassName is the storage class of the data volumes. The cluster default is used when empty.
+	// +kubebuilder:validation:Optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// Size is the requested size of each data volume.
+	// Increasing it expands the existing volumes online if the storage class allows volume expansion.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:default="1Gi"
+	Size resource.Quantity `json:"size"`
+	// AccessModes are the access modes of each data volume.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default={"ReadWriteOnce"}
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// RDB configures point-in-time snapshots of the dataset.
+	// +kubebuilder:validation:Optional
+	RDB *RDB `json:"rdb,omitempty"`
+	// AOF configures the append only file.
+	// +kubebuilder:validation:Optional
+	AOF *AOF `json:"aof,omitempty"`
+}
+
+// RDB defines the snapshot configuration for Redis.
+type RDB struct {
+	// Disabled turns off RDB snapshots entirely.
+	// +kubebuilder:validation:Optional
+	Disabled bool `json:"disabled,omitempty"`
+	// SaveRules are the snapshot rules in "<seconds> <changes>" form, e.g. "900 1".
+	// Redis defaults are used when empty.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:items:Pattern=`^[0-9]+ [0-9]+$`
+	SaveRules []string `json:"saveRules,omitempty"`
+}
+
+// AppendFsync is the fsync policy of the append only file.
+// +kubebuilder:validation:Enum=always;everysec;no
+type AppendFsync string
+
+// AOF defines the append only file configuration for Redis.
+type AOF struct {
+	// Enabled turns on the append only file.
+	// +kubebuilder:validation:Optional
+	Enabled bool `json:"enabled,omitempty"`
+	// AppendFsync is the fsync policy of the append only file.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=everysec
+	AppendFsync AppendFsync `json:"appendFsync,omitempty"`
 }
 
 // Service defines the service configuration for Redis.
@@ -76,19 +433,155 @@ type Service struct {
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:default=6379
 	Port *int32 `json:"port,omitempty"`
+	// ReadService defines an optional second service that load-balances across replicas only.
+	// It is only created in replication mode.
+	// +kubebuilder:validation:Optional
+	ReadService *ReadService `json:"readService,omitempty"`
 }
 
+// ReadService defines the service configuration for reads served by Redis replicas.
+type ReadService struct {
+	// Name is the name of the read service.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+	// Type is the type of service (ClusterIP, NodePort, LoadBalancer).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	Type string `json:"type,omitempty"`
+	// Port is the port on which the read service will be exposed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=6379
+	Port *int32 `json:"port,omitempty"`
+}
+
+// Phase summarizes the state of a Redis instance.
+// +kubebuilder:validation:Enum=Pending;Updating;Degraded;Ready
+type Phase string
+
+const (
+	// PhasePending means no Redis pod is ready yet.
+	PhasePending Phase = "Pending"
+	// PhaseUpdating means the Redis pods are being rolled onto a new pod template.
+	PhaseUpdating Phase = "Updating"
+	// PhaseDegraded means fewer Redis pods than desired are ready.
+	PhaseDegraded Phase = "Degraded"
+	// PhaseReady means every desired Redis pod is ready.
+	PhaseReady Phase = "Ready"
+)
+
 // RedisStatus defines the observed state of Redis.
 type RedisStatus struct {
 	// PasswordSecretName is the name of the secret containing the Redis password.
 	PasswordSecretName string `json:"passwordSecretName"`
+	// Phase summarizes the state of the instance.
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+	// ObservedGeneration is the generation of the spec the status was last computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Version is the Redis version reported by INFO server.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Primary is the name of the pod currently acting as the replication primary.
+	// +optional
+	Primary string `json:"primary,omitempty"`
+	// Replicas is the number of Redis pods, as reported through the scale subresource.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of Redis pods passing their readiness probe.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the Redis pods, used by the scale subresource and autoscalers.
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// Pods lists the Redis pods with their role.
+	// +optional
+	Pods []RedisPodStatus `json:"pods,omitempty"`
+	// PrimaryEndpoint is the host:port of the service that accepts writes.
+	// +optional
+	PrimaryEndpoint string `json:"primaryEndpoint,omitempty"`
+	// ReadEndpoint is the host:port of the service that load-balances reads across replicas.
+	// +optional
+	ReadEndpoint string `json:"readEndpoint,omitempty"`
+	// LastPasswordRotationTime is when the last password rotation completed.
+	// +optional
+	LastPasswordRotationTime *metav1.Time `json:"lastPasswordRotationTime,omitempty"`
+	// PasswordRotationStartTime is when the secret was updated by the running password rotation.
+	// The old password is accepted until the grace period has passed from then.
+	// +optional
+	PasswordRotationStartTime *metav1.Time `json:"passwordRotationStartTime,omitempty"`
+	// LastBackupTime is when the last scheduled backup completed.
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+	// TLSCertificateNotAfter is when the TLS server certificate expires.
+	// +optional
+	TLSCertificateNotAfter *metav1.Time `json:"tlsCertificateNotAfter,omitempty"`
+	// Cluster is the slot map and node state observed in cluster mode.
+	// +optional
+	Cluster *ClusterStatus `json:"cluster,omitempty"`
 	// Conditions store the status conditions of the Redis instances
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// RedisPodStatus is the observed state of a single Redis pod.
+type RedisPodStatus struct {
+	// Name is the name of the pod.
+	Name string `json:"name"`
+	// Role is primary or replica. Pods of a standalone instance are primaries.
+	// +optional
+	Role string `json:"role,omitempty"`
+	// Ready reports whether the pod passes its readiness probe.
+	Ready bool `json:"ready"`
+}
+
+// ClusterStatus is the observed state of a Redis Cluster.
+type ClusterStatus struct {
+	// State is the cluster_state reported by CLUSTER INFO, ok or fail.
+	State string `json:"state,omitempty"`
+	// AssignedSlots is the number of hash slots served by a primary.
+	AssignedSlots int32 `json:"assignedSlots,omitempty"`
+	// Shards is the number of shards whose pods are kept running. It exceeds spec.cluster.shards
+	// while surplus shards are drained of their slots.
+	Shards int32 `json:"shards,omitempty"`
+	// Nodes lists every node of the cluster.
+	// +optional
+	Nodes []ClusterNodeStatus `json:"nodes,omitempty"`
+}
+
+// ClusterNodeStatus is the observed state of a single Redis Cluster node.
+type ClusterNodeStatus struct {
+	// Pod is the name of the pod running the node.
+	Pod string `json:"pod,omitempty"`
+	// ID is the cluster node ID.
+	ID string `json:"id"`
+	// Role is primary or replica.
+	Role string `json:"role"`
+	// PrimaryID is the node ID of the primary a replica replicates from.
+	// +optional
+	PrimaryID string `json:"primaryID,omitempty"`
+	// Slots are the hash slot ranges served by a primary.
+	// +optional
+	Slots []string `json:"slots,omitempty"`
+	// Connected reports whether the cluster bus link to the node is up.
+	Connected bool `json:"connected"`
+	// Failed reports whether the cluster considers the node failed.
+	// +optional
+	Failed bool `json:"failed,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=`.status.readyReplicas`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="Endpoint",type=string,JSONPath=`.status.primaryEndpoint`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Redis is the Schema for the redis API.
 type Redis struct {
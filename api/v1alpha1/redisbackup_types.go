@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// BackupAccessKeyIDKey is the key of the access key ID in the S3 credentials secret.
+	BackupAccessKeyIDKey = "AWS_ACCESS_KEY_ID"
+	// BackupSecretAccessKeyKey is the key of the secret access key in the S3 credentials secret.
+	BackupSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// RedisBackupSpec defines the desired state of RedisBackup.
+// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="spec is immutable"
+type RedisBackupSpec struct {
+	// RedisName is the name of the Redis instance in the same namespace to back up.
+	// The snapshot is taken from the primary, or from the first running pod of a standalone instance.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	RedisName string `json:"redisName"`
+	// Destination is where the RDB snapshot is stored.
+	// +kubebuilder:validation:Required
+	Destination BackupDestination `json:"destination"`
+	// DeletionPolicy decides whether the stored snapshot is deleted together with the RedisBackup.
+	// Backups taken by spec.backup of a Redis are deleted with their snapshot.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default=Retain
+	DeletionPolicy BackupDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// BackupDeletionPolicy decides what happens to the stored snapshot when a RedisBackup is deleted.
+// +kubebuilder:validation:Enum=Retain;Delete
+type BackupDeletionPolicy string
+
+const (
+	// BackupDeletionPolicyRetain keeps the stored snapshot.
+	BackupDeletionPolicyRetain BackupDeletionPolicy = "Retain"
+	// BackupDeletionPolicyDelete deletes the stored snapshot before the RedisBackup is removed.
+	BackupDeletionPolicyDelete BackupDeletionPolicy = "Delete"
+)
+
+// BackupDestination defines where an RDB snapshot is stored. Exactly one of S3 and PVC must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.s3) != has(self.pvc)",message="exactly one of s3 or pvc must be set"
+type BackupDestination struct {
+	// S3 uploads the snapshot to an S3-compatible object store.
+	// +kubebuilder:validation:Optional
+	S3 *S3Destination `json:"s3,omitempty"`
+	// PVC writes the snapshot to an existing PersistentVolumeClaim.
+	// +kubebuilder:validation:Optional
+	PVC *PVCDestination `json:"pvc,omitempty"`
+}
+
+// S3Destination defines a bucket of an S3-compatible object store, such as AWS S3 or MinIO.
+type S3Destination struct {
+	// Endpoint is the URL of the object store, for example https://s3.eu-central-1.amazonaws.com
+	// or http://minio.minio.svc:9000.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+	// Bucket is the name of the bucket the snapshot is uploaded to.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to the object key <redis>/<backup>.rdb.
+	// +kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+	// CredentialsSecretName is the name of a secret holding the access key ID under AWS_ACCESS_KEY_ID
+	// and the secret access key under AWS_SECRET_ACCESS_KEY.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	CredentialsSecretName string `json:"credentialsSecretName"`
+	// InsecureSkipVerify skips the verification of the endpoint's TLS certificate.
+	// +kubebuilder:validation:Optional
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+	// Image is the MinIO client image uploading the snapshot.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default="minio/mc"
+	Image string `json:"image,omitempty"`
+}
+
+// PVCDestination defines a directory on an existing PersistentVolumeClaim.
+type PVCDestination struct {
+	// ClaimName is the name of the PersistentVolumeClaim in the same namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+	// Path is the directory on the volume the snapshot is written to as <redis>/<backup>.rdb.
+	// +kubebuilder:validation:Optional
+	Path string `json:"path,omitempty"`
+}
+
+// BackupPhase is the progress of a RedisBackup.
+// +kubebuilder:validation:Enum=Pending;Snapshotting;Uploading;Completed;Failed
+type BackupPhase string
+
+const (
+	// BackupPhasePending means the backup waits for a running Redis pod.
+	BackupPhasePending BackupPhase = "Pending"
+	// BackupPhaseSnapshotting means BGSAVE was triggered and the operator waits for LASTSAVE to advance.
+	BackupPhaseSnapshotting BackupPhase = "Snapshotting"
+	// BackupPhaseUploading means the backup Job fetches and stores the snapshot.
+	BackupPhaseUploading BackupPhase = "Uploading"
+	// BackupPhaseCompleted means the snapshot is stored at status.location.
+	BackupPhaseCompleted BackupPhase = "Completed"
+	// BackupPhaseFailed means the backup failed and is not retried.
+	BackupPhaseFailed BackupPhase = "Failed"
+)
+
+// RedisBackupStatus defines the observed state of RedisBackup.
+type RedisBackupStatus struct {
+	// Phase is the progress of the backup.
+	// +optional
+	Phase BackupPhase `json:"phase,omitempty"`
+	// Pod is the name of the Redis pod the snapshot is taken from.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// JobName is the name of the Job fetching and storing the snapshot.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// Location is the URL of the stored snapshot, s3://<bucket>/<key> or pvc://<claim>/<path>.
+	// +optional
+	Location string `json:"location,omitempty"`
+	// Size is the size of the snapshot in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// Checksum is the SHA-256 checksum of the snapshot, formatted as sha256:<hex>.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+	// StartTime is when BGSAVE was triggered.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when the snapshot was stored.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Conditions store the status conditions of the RedisBackup.
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Redis",type=string,JSONPath=`.spec.redisName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.size`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RedisBackup is the Schema for the redisbackups API. It takes a single RDB snapshot of a Redis instance.
+type RedisBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedisBackupSpec   `json:"spec,omitempty"`
+	Status RedisBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RedisBackupList contains a list of RedisBackup.
+type RedisBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedisBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RedisBackup{}, &RedisBackupList{})
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RedisUserSpec defines the desired state of RedisUser.
+type RedisUserSpec struct {
+	// RedisName is the name of the Redis instance in the same namespace the user is created on.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="redisName is immutable"
+	RedisName string `json:"redisName"`
+	// Username is the name of the ACL user. Defaults to the name of the RedisUser.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Pattern=`^[^\s<>]+$`
+	// +kubebuilder:validation:XValidation:rule="self != 'default'",message="the default user is managed by the Redis instance"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="username is immutable"
+	Username string `json:"username,omitempty"`
+	// Commands are the ACL command rules of the user, applied in order, for example +@read or -flushall.
+	// Without rules the user may not run any command.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:items:Pattern=`^[+-]\S+$`
+	Commands []string `json:"commands,omitempty"`
+	// Keys are the key patterns the user may access, for example app:*.
+	// +kubebuilder:validation:Optional
+	Keys []string `json:"keys,omitempty"`
+	// Channels are the Pub/Sub channel patterns the user may access.
+	// +kubebuilder:validation:Optional
+	Channels []string `json:"channels,omitempty"`
+	// PasswordSecretName is the name of a secret holding the password of the user under the password key.
+	// Without it the operator generates a password.
+	// +kubebuilder:validation:Optional
+	PasswordSecretName string `json:"passwordSecretName,omitempty"`
+}
+
+// RedisUserStatus defines the observed state of RedisUser.
+type RedisUserStatus struct {
+	// CredentialsSecretName is the name of the secret holding the username, password and endpoint of the user.
+	// +optional
+	CredentialsSecretName string `json:"credentialsSecretName,omitempty"`
+	// Username is the name of the ACL user the operator manages, so it is removed when spec.username changes.
+	// +optional
+	Username string `json:"username,omitempty"`
+	// Conditions store the status conditions of the RedisUser.
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:validation:XValidation:rule="has(self.spec.username) || self.metadata.name != 'default'",message="a RedisUser named default must set spec.username, the default user is managed by the Redis instance"
+
+// RedisUser is the Schema for the redisusers API. It declares an ACL user on every pod of a Redis instance.
+type RedisUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RedisUserSpec   `json:"spec,omitempty"`
+	Status RedisUserStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RedisUserList contains a list of RedisUser.
+type RedisUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RedisUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RedisUser{}, &RedisUserList{})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// defaultTargetCPUUtilizationPercentage is the CPU utilization pods are scaled at when spec.autoscaling sets no target.
+const defaultTargetCPUUtilizationPercentage = 80
+
+// reconcileAutoscaler ensures the HorizontalPodAutoscaler scaling the Redis through its scale subresource
+// matches spec.autoscaling, and removes it once autoscaling is disabled.
+func (r *RedisReconciler) reconcileAutoscaler(ctx context.Context, redis *v1alpha1.Redis) error {
+	logger := log.FromContext(ctx)
+
+	if redis.Spec.Autoscaling == nil {
+		return r.deleteIfOwned(ctx, redis, &autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: redis.Name, Namespace: redis.Namespace}})
+	}
+
+	desired, err := r.autoscalerForRedis(redis)
+	if err != nil {
+		return err
+	}
+	found := &autoscalingv2.HorizontalPodAutoscaler{}
+	err = r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: redis.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating a new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", desired.Namespace, "HorizontalPodAutoscaler.Name", desired.Name)
+			if err = r.Create(ctx, desired); err != nil {
+				return err
+			}
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "CreatedAutoscaler", fmt.Sprintf("Created horizontal pod autoscaler %s", desired.Name))
+			return nil
+		}
+		return err
+	}
+
+	if reflect.DeepEqual(found.Spec, desired.Spec) {
+		return nil
+	}
+	patch := client.MergeFrom(found.DeepCopy())
+	found.Spec = desired.Spec
+	if err := r.Patch(ctx, found, patch); err != nil {
+		return err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "UpdatedAutoscaler", fmt.Sprintf("Updated horizontal pod autoscaler %s", desired.Name))
+	return nil
+}
+
+// autoscalerForRedis returns the HorizontalPodAutoscaler scaling the Redis pods on their resource utilization.
+func (r *RedisReconciler) autoscalerForRedis(redis *v1alpha1.Redis) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	autoscaling := redis.Spec.Autoscaling
+
+	var metrics []autoscalingv2.MetricSpec
+	if target := autoscaling.TargetCPUUtilizationPercentage; target != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, *target))
+	}
+	if target := autoscaling.TargetMemoryUtilizationPercentage; target != nil {
+		metrics = append(metrics, resourceMetric(corev1.ResourceMemory, *target))
+	}
+	if len(metrics) == 0 {
+		metrics = append(metrics, resourceMetric(corev1.ResourceCPU, defaultTargetCPUUtilizationPercentage))
+	}
+
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redis.Name,
+			Namespace: redis.Namespace,
+			Labels:    labelsForRedis(redis.Name),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: v1alpha1.GroupVersion.String(),
+				Kind:       "Redis",
+				Name:       redis.Name,
+			},
+			MinReplicas: ptr.To(autoscaling.MinReplicas),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+	if err := ctrl.SetControllerReference(redis, hpa, r.Scheme); err != nil {
+		return nil, err
+	}
+	return hpa, nil
+}
+
+// resourceMetric returns a metric scaling on the average utilization of a resource across the Redis pods.
+func resourceMetric(name corev1.ResourceName, averageUtilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: ptr.To(averageUtilization),
+			},
+		},
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// scheduledBackupLabel marks the RedisBackups taken by spec.backup with the name of their Redis.
+	scheduledBackupLabel = "redis.yazio.com/scheduled-backup"
+	// scheduledTimeAnnotation records the schedule time a scheduled RedisBackup was taken for.
+	scheduledTimeAnnotation = "redis.yazio.com/scheduled-time"
+	// maxMissedSchedules bounds the search for the most recent missed schedule time.
+	maxMissedSchedules = 10000
+)
+
+// reconcileScheduledBackups creates a RedisBackup whenever spec.backup.schedule is due, prunes completed
+// backups the retention policy no longer selects and reports the outcome of the most recent scheduled
+// backup. A schedule missed while a backup is running or the operator is down is caught up once; older
+// missed schedule times are skipped. It returns the delay until the next schedule time.
+func (r *RedisReconciler) reconcileScheduledBackups(ctx context.Context, redis *v1alpha1.Redis) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	if redis.Spec.Backup == nil {
+		// Backups taken so far are left alone, they outlive the schedule just like they outlive the instance.
+		return 0, r.removeCondition(ctx, redis, conditionBackupSucceeded)
+	}
+	spec := redis.Spec.Backup
+	schedule, err := cron.ParseStandard(spec.Schedule)
+	if err != nil {
+		return 0, r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionBackupSucceeded,
+			Status:  metav1.ConditionFalse,
+			Reason:  "InvalidSchedule",
+			Message: fmt.Sprintf("Invalid schedule %q: %s", spec.Schedule, err),
+		})
+	}
+
+	backups, err := r.scheduledBackups(ctx, redis)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	last := redis.CreationTimestamp.UTC()
+	if len(backups) > 0 {
+		last = scheduledTime(&backups[0])
+	}
+	if due := lastScheduleTime(schedule, last, now); !due.IsZero() && !spec.Suspend && !backupRunning(backups) {
+		backup := scheduledBackupForRedis(redis, due)
+		logger.Info("Creating a scheduled RedisBackup", "RedisBackup.Namespace", backup.Namespace, "RedisBackup.Name", backup.Name)
+		if err := r.Create(ctx, backup); err != nil && !errors.IsAlreadyExists(err) {
+			return 0, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "ScheduledBackup", fmt.Sprintf("Created backup %s scheduled for %s", backup.Name, due.Format(time.RFC3339)))
+	}
+
+	for _, backup := range backupsToPrune(backups, spec.Retention) {
+		logger.Info("Pruning a scheduled RedisBackup", "RedisBackup.Namespace", backup.Namespace, "RedisBackup.Name", backup.Name)
+		if err := r.Delete(ctx, backup); client.IgnoreNotFound(err) != nil {
+			return 0, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "PrunedBackup", fmt.Sprintf("Deleted backup %s", backup.Name))
+	}
+
+	if err := r.reportScheduledBackup(ctx, redis, backups); err != nil {
+		return 0, err
+	}
+	return schedule.Next(now).Sub(now), nil
+}
+
+// reportScheduledBackup sets the BackupSucceeded condition from the most recent finished scheduled backup
+// and records when the most recent one completed.
+func (r *RedisReconciler) reportScheduledBackup(ctx context.Context, redis *v1alpha1.Redis, backups []v1alpha1.RedisBackup) error {
+	var latest, completed *v1alpha1.RedisBackup
+	for i := range backups {
+		backup := &backups[i]
+		if latest == nil && backupFinished(backup) {
+			latest = backup
+		}
+		if completed == nil && backup.Status.Phase == v1alpha1.BackupPhaseCompleted {
+			completed = backup
+		}
+	}
+
+	if completed != nil && completed.Status.CompletionTime != nil &&
+		(redis.Status.LastBackupTime == nil || redis.Status.LastBackupTime.Before(completed.Status.CompletionTime)) {
+		base := redis.DeepCopy()
+		redis.Status.LastBackupTime = completed.Status.CompletionTime.DeepCopy()
+		if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+			return err
+		}
+	}
+
+	if latest == nil {
+		return nil
+	}
+	if latest.Status.Phase == v1alpha1.BackupPhaseCompleted {
+		return r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionBackupSucceeded,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Completed",
+			Message: fmt.Sprintf("Backup %s stored at %s", latest.Name, latest.Status.Location),
+		})
+	}
+	message := fmt.Sprintf("Backup %s failed", latest.Name)
+	if condition := meta.FindStatusCondition(latest.Status.Conditions, conditionCompleted); condition != nil {
+		message = fmt.Sprintf("Backup %s failed: %s", latest.Name, condition.Message)
+	}
+	return r.setCondition(ctx, redis, metav1.Condition{
+		Type:    conditionBackupSucceeded,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Failed",
+		Message: message,
+	})
+}
+
+// scheduledBackups returns the scheduled RedisBackups of the Redis that are not being deleted, the most
+// recently scheduled first.
+func (r *RedisReconciler) scheduledBackups(ctx context.Context, redis *v1alpha1.Redis) ([]v1alpha1.RedisBackup, error) {
+	backupList := &v1alpha1.RedisBackupList{}
+	if err := r.List(ctx, backupList, client.InNamespace(redis.Namespace), client.MatchingLabels{scheduledBackupLabel: redis.Name}); err != nil {
+		return nil, err
+	}
+
+	backups := make([]v1alpha1.RedisBackup, 0, len(backupList.Items))
+	for _, backup := range backupList.Items {
+		if backup.DeletionTimestamp.IsZero() {
+			backups = append(backups, backup)
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return scheduledTime(&backups[i]).After(scheduledTime(&backups[j]))
+	})
+	return backups, nil
+}
+
+// scheduledBackupForRedis returns the RedisBackup taken for the given schedule time. Its name is derived from
+// the schedule time, so a schedule time is never backed up twice, and bounded, so the names of its Jobs fit.
+func scheduledBackupForRedis(redis *v1alpha1.Redis, scheduled time.Time) *v1alpha1.RedisBackup {
+	labels := labelsForRedis(redis.Name)
+	labels[scheduledBackupLabel] = redis.Name
+	// The backup is not owned by the Redis, so its snapshot is kept when the instance is deleted.
+	return &v1alpha1.RedisBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        boundedName(redis.Name, fmt.Sprintf("-%d", scheduled.Unix()/60)),
+			Namespace:   redis.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{scheduledTimeAnnotation: scheduled.Format(time.RFC3339)},
+		},
+		Spec: v1alpha1.RedisBackupSpec{
+			RedisName:      redis.Name,
+			Destination:    *redis.Spec.Backup.Destination.DeepCopy(),
+			DeletionPolicy: v1alpha1.BackupDeletionPolicyDelete,
+		},
+	}
+}
+
+// lastScheduleTime returns the most recent schedule time after last and not after now, or the zero time
+// if none passed.
+func lastScheduleTime(schedule cron.Schedule, last time.Time, now time.Time) time.Time {
+	var due time.Time
+	for next, i := schedule.Next(last), 0; !next.IsZero() && !next.After(now) && i < maxMissedSchedules; next, i = schedule.Next(next), i+1 {
+		due = next
+	}
+	return due
+}
+
+// scheduledTime returns the schedule time a scheduled backup was taken for, falling back to its creation time.
+func scheduledTime(backup *v1alpha1.RedisBackup) time.Time {
+	if scheduled, err := time.Parse(time.RFC3339, backup.Annotations[scheduledTimeAnnotation]); err == nil {
+		return scheduled
+	}
+	return backup.CreationTimestamp.UTC()
+}
+
+// backupRunning reports whether any of the backups has not finished yet.
+func backupRunning(backups []v1alpha1.RedisBackup) bool {
+	for i := range backups {
+		if !backupFinished(&backups[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupsToPrune returns the scheduled backups to delete from the backups sorted by schedule time, the most
+// recent first. Completed backups are kept if any retention rule selects them, or if there is no rule.
+// Failed backups are deleted once a more recent backup completed. Running backups are always kept.
+func backupsToPrune(backups []v1alpha1.RedisBackup, retention v1alpha1.BackupRetention) []*v1alpha1.RedisBackup {
+	keepAll := retention.KeepLast == 0 && retention.KeepDaily == 0 && retention.KeepWeekly == 0
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	completed := 0
+
+	var prune []*v1alpha1.RedisBackup
+	for i := range backups {
+		backup := &backups[i]
+		switch backup.Status.Phase {
+		case v1alpha1.BackupPhaseCompleted:
+			scheduled := scheduledTime(backup).UTC()
+			year, week := scheduled.ISOWeek()
+			day, isoWeek := scheduled.Format(time.DateOnly), fmt.Sprintf("%d-W%02d", year, week)
+
+			keep := keepAll || completed < int(retention.KeepLast)
+			if !days[day] && len(days) < int(retention.KeepDaily) {
+				days[day] = true
+				keep = true
+			}
+			if !weeks[isoWeek] && len(weeks) < int(retention.KeepWeekly) {
+				weeks[isoWeek] = true
+				keep = true
+			}
+			completed++
+			if !keep {
+				prune = append(prune, backup)
+			}
+		case v1alpha1.BackupPhaseFailed:
+			if completed > 0 {
+				prune = append(prune, backup)
+			}
+		}
+	}
+	return prune
+}
+
+// redisForBackup maps a scheduled or final RedisBackup to the Redis it was taken for.
+func (r *RedisReconciler) redisForBackup(ctx context.Context, obj client.Object) []reconcile.Request {
+	for _, label := range []string{scheduledBackupLabel, finalBackupLabel} {
+		if name, ok := obj.GetLabels()[label]; ok {
+			return []reconcile.Request{{NamespacedName: client.ObjectKey{Name: name, Namespace: obj.GetNamespace()}}}
+		}
+	}
+	return nil
+}
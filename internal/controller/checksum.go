@@ -0,0 +1,158 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// checksumAnnotation is the pod template annotation carrying the checksum of the Secrets and ConfigMaps
+// the Redis pods consume. A changed checksum rolls the pods.
+const checksumAnnotation = "redis.yazio.com/checksum"
+
+// podChecksum returns a checksum of every Secret and ConfigMap the Redis pods consume. The rendered
+// redis.conf is left out: its directives are applied to running pods or covered by the config hash.
+// A rotated password secret is left out as well, since rotation updates the running pods in place.
+func (r *RedisReconciler) podChecksum(ctx context.Context, redis *v1alpha1.Redis) (string, error) {
+	h := sha256.New()
+
+	for _, name := range consumedSecrets(redis) {
+		if rotatesPassword(redis) && name == redis.Spec.PasswordSecretName {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: redis.Namespace}, secret); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", err
+			}
+			// Optional references may be missing; creating them later changes the checksum.
+			fmt.Fprintf(h, "secret/%s missing\n", name)
+			continue
+		}
+		data := make(map[string]string, len(secret.Data))
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+		writeChecksumData(h, "secret/"+name, data)
+	}
+
+	for _, name := range consumedConfigMaps(redis) {
+		cm := &corev1.ConfigMap{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: redis.Namespace}, cm); err != nil {
+			if !errors.IsNotFound(err) {
+				return "", err
+			}
+			fmt.Fprintf(h, "configmap/%s missing\n", name)
+			continue
+		}
+		writeChecksumData(h, "configmap/"+name, cm.Data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// writeChecksumData writes the data of a Secret or ConfigMap to the hash in a stable order.
+func writeChecksumData(h hash.Hash, prefix string, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s/%s=%q\n", prefix, key, data[key])
+	}
+}
+
+// consumedSecrets returns the names of the Secrets the Redis pods consume, sorted.
+func consumedSecrets(redis *v1alpha1.Redis) []string {
+	names := []string{redis.Spec.PasswordSecretName}
+	if hasTLS(redis) {
+		names = append(names, tlsSecretName(redis))
+	}
+	if redis.Spec.Env != nil {
+		for _, env := range *redis.Spec.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// consumedConfigMaps returns the names of the ConfigMaps the Redis pods consume through spec.env, sorted.
+func consumedConfigMaps(redis *v1alpha1.Redis) []string {
+	var names []string
+	if redis.Spec.Env != nil {
+		for _, env := range *redis.Spec.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// withChecksum stamps the checksum of the consumed Secrets and ConfigMaps on a pod template.
+func withChecksum(template corev1.PodTemplateSpec, checksum string) corev1.PodTemplateSpec {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[checksumAnnotation] = checksum
+	return template
+}
+
+// redisForConsumedObject maps a Secret or ConfigMap to the Redis instances whose pods consume it,
+// so that edits to Secrets and ConfigMaps the operator does not own still roll the pods.
+func (r *RedisReconciler) redisForConsumedObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	redisList := &v1alpha1.RedisList{}
+	if err := r.List(ctx, redisList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Unable to list Redis instances")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, redis := range redisList.Items {
+		var consumed []string
+		switch obj.(type) {
+		case *corev1.Secret:
+			consumed = consumedSecrets(&redis)
+		case *corev1.ConfigMap:
+			consumed = consumedConfigMaps(&redis)
+		}
+		if slices.Contains(consumed, obj.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&redis)})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,457 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// clusterSlots is the number of hash slots of a Redis Cluster.
+	clusterSlots = 16384
+	// clusterConfigFile is the node table Redis maintains in the data directory.
+	clusterConfigFile = redisDataDir + "/nodes.conf"
+	// clusterNodeTimeoutMillis is how long a node may be unreachable before it is considered failing.
+	clusterNodeTimeoutMillis = 5000
+)
+
+// clusterNode is a single entry of the CLUSTER NODES reply.
+type clusterNode struct {
+	ID        string
+	IP        string
+	Hostname  string
+	Myself    bool
+	Primary   bool
+	Failed    bool
+	Handshake bool
+	PrimaryID string
+	Connected bool
+	Slots     []string
+	// Migrating maps slots being migrated away from the node to their destination node ID.
+	// CLUSTER NODES only lists them for the node answering the command.
+	Migrating map[int]string
+	// Importing maps slots being imported into the node to their source node ID, listed like Migrating.
+	Importing map[int]string
+}
+
+// clusterMember is a running Redis pod together with its own view of the cluster.
+type clusterMember struct {
+	pod   *corev1.Pod
+	rdb   *goredis.Client
+	nodes []clusterNode
+}
+
+// self returns the entry of the member itself in its view of the cluster.
+func (m *clusterMember) self() *clusterNode {
+	for i := range m.nodes {
+		if m.nodes[i].Myself {
+			return &m.nodes[i]
+		}
+	}
+	return nil
+}
+
+// id returns the node ID of the member itself.
+func (m *clusterMember) id() string {
+	if self := m.self(); self != nil {
+		return self.ID
+	}
+	return ""
+}
+
+// knows reports whether the member has a node with the given ID in its view of the cluster.
+func (m *clusterMember) knows(id string) bool {
+	for _, node := range m.nodes {
+		if node.ID == id && !node.Handshake {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileCluster bootstraps a Redis Cluster once every pod of the StatefulSet is running: it joins the
+// nodes with CLUSTER MEET, assigns the hash slots to one primary per shard and makes the remaining pods
+// of a shard replicate its primary. The observed slot map is recorded in the status. It reports whether
+// the cluster is complete and healthy, so the caller can requeue otherwise.
+func (r *RedisReconciler) reconcileCluster(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	logger := log.FromContext(ctx)
+	if !isClustered(redis) {
+		return true, r.setClusterStatus(ctx, redis, nil)
+	}
+
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+	if len(pods) < int(*redisReplicas(redis)) {
+		return false, nil
+	}
+	for i := range pods {
+		if !podRunning(&pods[i]) {
+			return false, nil
+		}
+	}
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+
+	members := make([]*clusterMember, 0, len(pods))
+	defer func() {
+		for _, member := range members {
+			_ = member.rdb.Close()
+		}
+	}()
+	for i := range pods {
+		member := &clusterMember{pod: &pods[i], rdb: redisClientForPod(redis, &pods[i], password, r.clientTLSConfig(redis))}
+		members = append(members, member)
+		reply, err := member.rdb.ClusterNodes(ctx).Result()
+		if err != nil {
+			logger.Error(err, "Unable to read the cluster nodes", "Pod.Name", member.pod.Name)
+			return false, nil
+		}
+		member.nodes = parseClusterNodes(reply)
+	}
+
+	if met, err := r.meetClusterNodes(ctx, redis, members); err != nil || met {
+		return false, err
+	}
+	seed := members[0]
+	for _, member := range members {
+		if len(member.nodes) < len(members) {
+			// Gossip has not yet spread every node to every other node.
+			return false, nil
+		}
+	}
+
+	changed, err := r.assignClusterSlots(ctx, redis, seed, members)
+	if err != nil || changed {
+		return false, err
+	}
+	changed = r.replicateClusterShards(ctx, redis, members)
+	resharding := false
+	if !changed {
+		if resharding, err = r.reconcileResharding(ctx, redis, members, password); err != nil {
+			return false, err
+		}
+		if err := r.forgetRemovedNodes(ctx, redis, pods, members); err != nil {
+			return false, err
+		}
+	}
+
+	info, err := seed.rdb.ClusterInfo(ctx).Result()
+	if err != nil {
+		logger.Error(err, "Unable to read the cluster info", "Pod.Name", seed.pod.Name)
+		return false, nil
+	}
+	status := clusterStatusFor(redis, pods, seed.nodes, parseInfo(info)["cluster_state"])
+	status.Shards = activeShards(redis, members)
+	for _, node := range status.Nodes {
+		if node.Pod == "" {
+			continue
+		}
+		for i := range pods {
+			if pods[i].Name == node.Pod {
+				if err := r.setPodRole(ctx, &pods[i], node.Role); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	if err := r.setClusterStatus(ctx, redis, status); err != nil {
+		return false, err
+	}
+
+	return !changed && !resharding && status.State == "ok" && status.AssignedSlots == clusterSlots, nil
+}
+
+// meetClusterNodes introduces every node to the first pod. Gossip then spreads the complete node table.
+// It reports whether any node had to be introduced.
+func (r *RedisReconciler) meetClusterNodes(ctx context.Context, redis *v1alpha1.Redis, members []*clusterMember) (bool, error) {
+	seed := members[0]
+	met := false
+	for _, member := range members[1:] {
+		if member.knows(seed.id()) && seed.knows(member.id()) {
+			continue
+		}
+		if err := member.rdb.ClusterMeet(ctx, seed.pod.Status.PodIP, strconv.Itoa(int(*redis.Spec.Port))).Err(); err != nil {
+			return false, err
+		}
+		log.FromContext(ctx).Info("Introduced node to the cluster", "Pod.Name", member.pod.Name)
+		met = true
+	}
+	if met {
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "ClusterMeet", fmt.Sprintf("Introduced nodes to %s", seed.pod.Name))
+	}
+	return met, nil
+}
+
+// assignClusterSlots spreads the hash slots evenly across the first pod of every shard when no slot
+// has been assigned yet. Clusters that already serve slots are left untouched.
+// It reports whether slots were assigned.
+func (r *RedisReconciler) assignClusterSlots(ctx context.Context, redis *v1alpha1.Redis, seed *clusterMember, members []*clusterMember) (bool, error) {
+	for _, node := range seed.nodes {
+		if len(node.Slots) > 0 {
+			return false, nil
+		}
+	}
+
+	shards := int(redis.Spec.Cluster.Shards)
+	for shard, slots := range slotRanges(shards) {
+		member := members[shard*clusterShardSize(redis)]
+		if err := member.rdb.ClusterAddSlotsRange(ctx, slots[0], slots[1]).Err(); err != nil {
+			return false, err
+		}
+		log.FromContext(ctx).Info("Assigned hash slots", "Pod.Name", member.pod.Name, "From", slots[0], "To", slots[1])
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "AssignedSlots", fmt.Sprintf("Assigned %d hash slots across %d shards", clusterSlots, shards))
+	return true, nil
+}
+
+// replicateClusterShards makes every empty primary replicate the primary of its shard.
+// Nodes that already replicate are left alone, so failovers within a shard are not undone.
+// It reports whether any replica was configured.
+func (r *RedisReconciler) replicateClusterShards(ctx context.Context, redis *v1alpha1.Redis, members []*clusterMember) bool {
+	logger := log.FromContext(ctx)
+
+	changed := false
+	for _, shard := range clusterShards(redis, members) {
+		primary := shardPrimary(shard)
+		if primary == nil {
+			continue
+		}
+		primaryID := primary.id()
+
+		for _, member := range shard {
+			self := member.self()
+			if member == primary || self == nil || !self.Primary || len(self.Slots) > 0 {
+				continue
+			}
+			if err := member.rdb.ClusterReplicate(ctx, primaryID).Err(); err != nil {
+				logger.Error(err, "Unable to configure cluster replica", "Pod.Name", member.pod.Name)
+				continue
+			}
+			changed = true
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "ConfiguredReplica", fmt.Sprintf("Pod %s now replicates node %s", member.pod.Name, primaryID))
+		}
+	}
+	return changed
+}
+
+// forgetRemovedNodes makes every node forget the nodes whose pods were removed after their shard was drained.
+// Such nodes are failed, serve no slots and cannot be mapped to any pod anymore.
+func (r *RedisReconciler) forgetRemovedNodes(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, members []*clusterMember) error {
+	for _, node := range members[0].nodes {
+		if !node.Failed || len(node.Slots) > 0 {
+			continue
+		}
+		host := node.Hostname
+		if host == "" {
+			host = node.IP
+		}
+		if podForHost(redis, pods, host) != nil {
+			continue
+		}
+		for _, member := range members {
+			if !member.knows(node.ID) {
+				continue
+			}
+			if err := member.rdb.ClusterForget(ctx, node.ID).Err(); err != nil {
+				return err
+			}
+		}
+		log.FromContext(ctx).Info("Forgot removed cluster node", "Node.ID", node.ID)
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "ForgotNode", fmt.Sprintf("Removed node %s from the cluster", node.ID))
+	}
+	return nil
+}
+
+// clusterShards groups the members, ordered by pod ordinal, into their shards.
+func clusterShards(redis *v1alpha1.Redis, members []*clusterMember) [][]*clusterMember {
+	size := clusterShardSize(redis)
+	var shards [][]*clusterMember
+	for start := 0; start+size <= len(members); start += size {
+		shards = append(shards, members[start:start+size])
+	}
+	return shards
+}
+
+// shardPrimary returns the member serving the slots of a shard. A shard that serves no slots yet,
+// such as one just added, is led by its first member for as long as that member is a primary.
+func shardPrimary(shard []*clusterMember) *clusterMember {
+	for _, member := range shard {
+		if self := member.self(); self != nil && self.Primary && len(self.Slots) > 0 {
+			return member
+		}
+	}
+	if self := shard[0].self(); self != nil && self.Primary {
+		return shard[0]
+	}
+	return nil
+}
+
+// activeShards returns the number of shards whose pods must keep running: every desired shard plus
+// every shard beyond them that still serves slots and is being drained.
+func activeShards(redis *v1alpha1.Redis, members []*clusterMember) int32 {
+	active := redis.Spec.Cluster.Shards
+	for i, shard := range clusterShards(redis, members) {
+		if primary := shardPrimary(shard); primary != nil && len(primary.self().Slots) > 0 && int32(i+1) > active {
+			active = int32(i + 1)
+		}
+	}
+	return active
+}
+
+// setClusterStatus records the observed cluster state on the status if it changed.
+func (r *RedisReconciler) setClusterStatus(ctx context.Context, redis *v1alpha1.Redis, status *v1alpha1.ClusterStatus) error {
+	if reflect.DeepEqual(redis.Status.Cluster, status) {
+		return nil
+	}
+	base := redis.DeepCopy()
+	redis.Status.Cluster = status
+	return r.Status().Patch(ctx, redis, client.MergeFrom(base))
+}
+
+// clusterStatusFor builds the cluster status from the node table of a single node.
+func clusterStatusFor(redis *v1alpha1.Redis, pods []corev1.Pod, nodes []clusterNode, state string) *v1alpha1.ClusterStatus {
+	status := &v1alpha1.ClusterStatus{State: state}
+	for _, node := range nodes {
+		nodeStatus := v1alpha1.ClusterNodeStatus{
+			ID:        node.ID,
+			Role:      roleReplica,
+			PrimaryID: node.PrimaryID,
+			Slots:     node.Slots,
+			Connected: node.Connected,
+			Failed:    node.Failed,
+		}
+		if node.Primary {
+			nodeStatus.Role = rolePrimary
+			status.AssignedSlots += countSlots(node.Slots)
+		}
+		host := node.Hostname
+		if host == "" {
+			host = node.IP
+		}
+		if pod := podForHost(redis, pods, host); pod != nil {
+			nodeStatus.Pod = pod.Name
+		}
+		status.Nodes = append(status.Nodes, nodeStatus)
+	}
+	return status
+}
+
+// parseClusterNodes parses the reply of CLUSTER NODES.
+func parseClusterNodes(reply string) []clusterNode {
+	var nodes []clusterNode
+	for _, line := range strings.Split(reply, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+
+		node := clusterNode{ID: fields[0], Connected: fields[7] == "connected"}
+		// The address has the form ip:port@cport[,hostname].
+		addr, hostname, _ := strings.Cut(fields[1], ",")
+		node.Hostname = hostname
+		if i := strings.LastIndex(strings.Split(addr, "@")[0], ":"); i >= 0 {
+			node.IP = addr[:i]
+		}
+		for _, flag := range strings.Split(fields[2], ",") {
+			switch flag {
+			case "myself":
+				node.Myself = true
+			case "master":
+				node.Primary = true
+			case "fail":
+				node.Failed = true
+			case "handshake":
+				node.Handshake = true
+			}
+		}
+		if fields[3] != "-" {
+			node.PrimaryID = fields[3]
+		}
+		for _, slot := range fields[8:] {
+			// Slots being migrated are listed in brackets as [slot->-destination] or [slot-<-source]
+			// and are still owned by their source node.
+			if !strings.HasPrefix(slot, "[") {
+				node.Slots = append(node.Slots, slot)
+				continue
+			}
+			entry := strings.Trim(slot, "[]")
+			if from, to, migrating := strings.Cut(entry, "->-"); migrating {
+				if number, err := strconv.Atoi(from); err == nil {
+					if node.Migrating == nil {
+						node.Migrating = map[int]string{}
+					}
+					node.Migrating[number] = to
+				}
+			} else if to, from, importing := strings.Cut(entry, "-<-"); importing {
+				if number, err := strconv.Atoi(to); err == nil {
+					if node.Importing == nil {
+						node.Importing = map[int]string{}
+					}
+					node.Importing[number] = from
+				}
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// slotRanges splits the hash slots into contiguous, evenly sized ranges, one per shard.
+func slotRanges(shards int) [][2]int {
+	ranges := make([][2]int, 0, shards)
+	start := 0
+	for shard := 0; shard < shards; shard++ {
+		end := start + clusterSlots/shards - 1
+		if shard < clusterSlots%shards {
+			end++
+		}
+		ranges = append(ranges, [2]int{start, end})
+		start = end + 1
+	}
+	return ranges
+}
+
+// countSlots returns the number of hash slots in a list of slots and slot ranges.
+func countSlots(slots []string) int32 {
+	return int32(len(expandSlots(slots)))
+}
+
+// clusterShardSize returns the number of pods in a shard. Shards occupy consecutive pod ordinals,
+// so adding shards only appends pods and never moves an existing pod to another shard.
+func clusterShardSize(redis *v1alpha1.Redis) int {
+	return int(1 + redis.Spec.Cluster.ReplicasPerShard)
+}
+
+// clusterBusPort returns the port of the cluster bus, which Redis derives from the client port.
+func clusterBusPort(redis *v1alpha1.Redis) int32 {
+	return *redis.Spec.Port + 10000
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// configVolumeName is the name of the writable volume holding the redis.conf Redis runs with.
+	configVolumeName = "config"
+	// configTemplateVolumeName is the name of the volume holding the rendered redis.conf.
+	configTemplateVolumeName = "config-template"
+	// redisConfigDir is the directory Redis reads and rewrites its redis.conf in.
+	redisConfigDir = "/etc/redis"
+	// redisConfigTemplateDir is the directory the rendered redis.conf is mounted at.
+	redisConfigTemplateDir = "/etc/redis-template"
+	// redisConfigKey is the config map key holding the rendered redis.conf.
+	redisConfigKey = "redis.conf"
+
+	// configHashAnnotation is the pod template annotation carrying the hash of the restart-required
+	// directives. Changing it rolls the pods.
+	configHashAnnotation = "redis.yazio.com/config-hash"
+	// appliedConfigAnnotation is the pod annotation carrying the hash of the hot-reloadable directives
+	// last applied to the pod with CONFIG SET.
+	appliedConfigAnnotation = "redis.yazio.com/applied-config-hash"
+	// appliedConfigKeysAnnotation is the pod annotation listing the hot-reloadable directives last applied
+	// to the pod, so directives later removed from spec.config are noticed.
+	appliedConfigKeysAnnotation = "redis.yazio.com/applied-config-keys"
+)
+
+// restartRequiredConfigKeys are the directives Redis only reads at startup and refuses to CONFIG SET.
+// Every other directive is applied to running pods.
+var restartRequiredConfigKeys = []string{
+	"aclfile",
+	"always-show-logo",
+	"appenddirname",
+	"appendfilename",
+	"bind-source-addr",
+	"cluster-config-file",
+	"cluster-port",
+	"daemonize",
+	"databases",
+	"disable-thp",
+	"enable-debug-command",
+	"enable-module-command",
+	"enable-protected-configs",
+	"io-threads",
+	"io-threads-do-reads",
+	"loadmodule",
+	"logfile",
+	"pidfile",
+	"rename-command",
+	"set-proc-title",
+	"supervised",
+	"syslog-enabled",
+	"syslog-facility",
+	"syslog-ident",
+	"tcp-backlog",
+	"tls-ca-cert-dir",
+	"tls-client-cert-file",
+	"tls-client-key-file",
+	"tls-client-key-file-pass",
+	"tls-dh-params-file",
+	"tls-key-file-pass",
+	"unixsocket",
+	"unixsocketperm",
+}
+
+// reconcileConfig ensures the config map holding the redis.conf rendered from spec.config exists,
+// and removes it once spec.config is emptied.
+func (r *RedisReconciler) reconcileConfig(ctx context.Context, redis *v1alpha1.Redis) error {
+	if managed := v1alpha1.ManagedConfigKeysIn(redis.Spec.Config); len(managed) > 0 {
+		message := fmt.Sprintf("spec.config must not set directives managed by the operator: %s", strings.Join(managed, ", "))
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "InvalidConfig", message)
+		return fmt.Errorf("%s", message)
+	}
+	if malformed := v1alpha1.MalformedConfigKeysIn(redis.Spec.Config); len(malformed) > 0 {
+		message := fmt.Sprintf("spec.config keys must not contain whitespace and values must not contain line breaks: %s", strings.Join(malformed, ", "))
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "InvalidConfig", message)
+		return fmt.Errorf("%s", message)
+	}
+
+	if !hasConfig(redis) {
+		return r.deleteIfOwned(ctx, redis, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName(redis), Namespace: redis.Namespace}})
+	}
+	_, err := r.ensureConfigMap(ctx, redis, r.configMapForRedis(redis))
+	return err
+}
+
+// reconcileLiveConfig applies the hot-reloadable directives of spec.config to every running Redis pod with
+// CONFIG SET and persists them with CONFIG REWRITE, so they survive container restarts. The hash of the
+// applied directives is recorded on the pod, so unchanged pods are skipped. Redis cannot reset a directive
+// to its default at runtime, so a pod still running with a directive removed from spec.config is deleted
+// and recreated from the rendered redis.conf instead, one pod at a time while all others are ready.
+// It reports whether every running pod is up to date.
+func (r *RedisReconciler) reconcileLiveConfig(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	logger := log.FromContext(ctx)
+	if !hasConfig(redis) {
+		return true, nil
+	}
+
+	hot, _ := splitConfig(redis.Spec.Config)
+	hash := configHash(hot)
+
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+
+	applied := true
+	// Pods are only restarted while every pod is ready, so at most one is down at a time.
+	restarting := false
+	for i := range pods {
+		if !podReady(&pods[i]) {
+			restarting = true
+		}
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) || pod.Annotations[appliedConfigAnnotation] == hash {
+			continue
+		}
+		if removed := removedConfigKeys(pod, hot); len(removed) > 0 {
+			applied = false
+			if restarting {
+				continue
+			}
+			if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+			restarting = true
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "RestartingPod", fmt.Sprintf("Restarting pod %s to reset config directives removed from spec.config: %s", pod.Name, strings.Join(removed, ", ")))
+			continue
+		}
+		if err := applyConfig(ctx, redis, pod, password, r.clientTLSConfig(redis), hot); err != nil {
+			logger.Error(err, "Unable to apply config", "Pod.Name", pod.Name)
+			applied = false
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[appliedConfigAnnotation] = hash
+		pod.Annotations[appliedConfigKeysAnnotation] = configKeys(hot)
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return false, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "AppliedConfig", fmt.Sprintf("Applied %d config directives to pod %s", len(hot), pod.Name))
+	}
+	return applied, nil
+}
+
+// removedConfigKeys returns the directives last applied to the pod that are no longer hot-reloadable
+// directives of spec.config. Pods without a record of the applied directives have none.
+func removedConfigKeys(pod *corev1.Pod, hot map[string]string) []string {
+	recorded := pod.Annotations[appliedConfigKeysAnnotation]
+	if recorded == "" {
+		return nil
+	}
+	current := strings.Split(configKeys(hot), ",")
+	var removed []string
+	for _, key := range strings.Split(recorded, ",") {
+		if !slices.Contains(current, key) {
+			removed = append(removed, key)
+		}
+	}
+	return removed
+}
+
+// configKeys returns the lowercased names of config directives, sorted and joined by commas.
+func configKeys(config map[string]string) string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, strings.ToLower(key))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// applyConfig sets the given directives on the Redis server of a pod and rewrites its redis.conf.
+func applyConfig(ctx context.Context, redis *v1alpha1.Redis, pod *corev1.Pod, password string, tlsConfig *tls.Config, config map[string]string) error {
+	rdb := redisClientForPod(redis, pod, password, tlsConfig)
+	defer func() { _ = rdb.Close() }()
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := rdb.ConfigSet(ctx, key, config[key]).Err(); err != nil {
+			return fmt.Errorf("setting %s: %w", key, err)
+		}
+	}
+	return rdb.ConfigRewrite(ctx).Err()
+}
+
+// addConfigToPodTemplate mounts the rendered redis.conf into the Redis pods. An init container copies it to
+// a writable volume, so CONFIG REWRITE can persist directives applied at runtime across container restarts.
+// CONFIG REWRITE also writes requirepass and masterauth in plain text, so the volume is memory-backed and
+// never reaches the disk of the node; it is only mounted into the Redis container, which holds the password
+// in its environment anyway. Only restart-required directives are hashed into the template, so changing any
+// other directive does not roll the pods.
+func addConfigToPodTemplate(redis *v1alpha1.Redis, template *corev1.PodTemplateSpec) {
+	_, restartRequired := splitConfig(redis.Spec.Config)
+	template.Annotations = map[string]string{configHashAnnotation: configHash(restartRequired)}
+
+	container := &template.Spec.Containers[0]
+	container.Args = append([]string{fmt.Sprintf("%s/%s", redisConfigDir, redisConfigKey)}, container.Args...)
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: configVolumeName, MountPath: redisConfigDir})
+
+	template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+		Image:     redis.Spec.Image,
+		Name:      "config",
+		Command:   []string{"cp", fmt.Sprintf("%s/%s", redisConfigTemplateDir, redisConfigKey), fmt.Sprintf("%s/%s", redisConfigDir, redisConfigKey)},
+		Resources: redis.Spec.Resources,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: configTemplateVolumeName, MountPath: redisConfigTemplateDir},
+			{Name: configVolumeName, MountPath: redisConfigDir},
+		},
+	})
+	template.Spec.Volumes = append(template.Spec.Volumes,
+		corev1.Volume{
+			Name: configTemplateVolumeName,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(redis)},
+			}},
+		},
+		corev1.Volume{
+			Name:         configVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}},
+		},
+	)
+}
+
+// splitConfig splits config directives into the ones that can be applied at runtime and the ones that
+// require a restart.
+func splitConfig(config map[string]string) (hot map[string]string, restartRequired map[string]string) {
+	hot, restartRequired = map[string]string{}, map[string]string{}
+	for key, value := range config {
+		if slices.Contains(restartRequiredConfigKeys, strings.ToLower(key)) {
+			restartRequired[key] = value
+		} else {
+			hot[key] = value
+		}
+	}
+	return hot, restartRequired
+}
+
+// configHash returns a stable hash of config directives.
+func configHash(config map[string]string) string {
+	sum := sha256.Sum256([]byte(redisConfig(config)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// configMapForRedis returns the config map holding the redis.conf rendered from spec.config.
+func (r *RedisReconciler) configMapForRedis(redis *v1alpha1.Redis) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configMapName(redis), Namespace: redis.Namespace, Labels: labelsForRedis(redis.Name)},
+		Data:       map[string]string{redisConfigKey: redisConfig(redis.Spec.Config)},
+	}
+
+	_ = ctrl.SetControllerReference(redis, cm, r.Scheme)
+
+	return cm
+}
+
+// redisConfig renders config directives as redis.conf lines, sorted so the output is stable.
+func redisConfig(config map[string]string) string {
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s %s\n", key, config[key])
+	}
+	return b.String()
+}
+
+// configMapName returns the name of the config map holding the rendered redis.conf.
+func configMapName(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-config", redis.Name)
+}
+
+// hasConfig reports whether spec.config sets any directive.
+func hasConfig(redis *v1alpha1.Redis) bool {
+	return len(redis.Spec.Config) > 0
+}
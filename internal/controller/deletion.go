@@ -0,0 +1,187 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// finalBackupLabel marks the RedisBackup taken by the Snapshot deletion policy with the name of its Redis.
+const finalBackupLabel = "redis.yazio.com/final-backup"
+
+// finalizeRedis applies spec.deletionPolicy to a deleted Redis and reports whether it completed, so the
+// finalizer can be removed. Everything else the operator created is removed by the garbage collector.
+func (r *RedisReconciler) finalizeRedis(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	// The operator no longer connects to the instance, so its cached TLS configuration is dropped.
+	r.tlsConfigs.forget(redis)
+
+	switch redis.Spec.DeletionPolicy {
+	case v1alpha1.DeletionPolicyRetain:
+		return true, r.retainData(ctx, redis)
+	case v1alpha1.DeletionPolicySnapshot:
+		taken, err := r.takeFinalSnapshot(ctx, redis)
+		if err != nil || !taken {
+			return false, err
+		}
+		return true, r.deleteData(ctx, redis)
+	default:
+		return true, r.deleteData(ctx, redis)
+	}
+}
+
+// deleteData deletes the data volume claims, which the StatefulSet leaves behind. The password Secret is
+// garbage collected if the operator created it.
+func (r *RedisReconciler) deleteData(ctx context.Context, redis *v1alpha1.Redis) error {
+	logger := log.FromContext(ctx)
+
+	claims, err := r.dataClaimsForRedis(ctx, redis)
+	if err != nil {
+		return err
+	}
+	for i := range claims {
+		claim := &claims[i]
+		if !claim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		logger.Info("Deleting a data volume claim", "PersistentVolumeClaim.Namespace", claim.Namespace, "PersistentVolumeClaim.Name", claim.Name)
+		if err := r.Delete(ctx, claim); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "DeletedVolumeClaim", fmt.Sprintf("Deleted persistent volume claim %s", claim.Name))
+	}
+	return nil
+}
+
+// retainData strips the owner references to the Redis and its StatefulSet from the password Secret and the
+// data volume claims, so the garbage collector leaves them behind.
+func (r *RedisReconciler) retainData(ctx context.Context, redis *v1alpha1.Redis) error {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: redis.Spec.PasswordSecretName, Namespace: redis.Namespace}, secret); client.IgnoreNotFound(err) != nil {
+		return err
+	} else if err == nil {
+		if err := r.orphan(ctx, redis, secret); err != nil {
+			return err
+		}
+	}
+
+	claims, err := r.dataClaimsForRedis(ctx, redis)
+	if err != nil {
+		return err
+	}
+	for i := range claims {
+		if err := r.orphan(ctx, redis, &claims[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphan removes the owner references to the Redis and its StatefulSet from an object.
+func (r *RedisReconciler) orphan(ctx context.Context, redis *v1alpha1.Redis, obj client.Object) error {
+	base := obj.DeepCopyObject().(client.Object)
+	var owners []metav1.OwnerReference
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == redis.UID || (owner.Kind == "StatefulSet" && owner.Name == redis.Name) {
+			continue
+		}
+		owners = append(owners, owner)
+	}
+	if len(owners) == len(obj.GetOwnerReferences()) {
+		return nil
+	}
+
+	obj.SetOwnerReferences(owners)
+	if err := r.Patch(ctx, obj, client.MergeFrom(base)); err != nil {
+		return err
+	}
+	kind := "secret"
+	if _, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+		kind = "persistent volume claim"
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "Retained", fmt.Sprintf("Retained %s %s", kind, obj.GetName()))
+	return nil
+}
+
+// takeFinalSnapshot creates a RedisBackup of the deleted Redis at spec.backup.destination and reports whether
+// it completed. A failed snapshot keeps blocking the deletion until spec.deletionPolicy is changed.
+func (r *RedisReconciler) takeFinalSnapshot(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if redis.Spec.Backup == nil {
+		return false, r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionFinalSnapshot,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NoDestination",
+			Message: "The Snapshot deletion policy requires spec.backup for the destination of the final backup",
+		})
+	}
+
+	// The name is derived from the deletion, so a Redis recreated under the same name gets a new final backup.
+	name := fmt.Sprintf("%s-final-%d", redis.Name, redis.DeletionTimestamp.Unix())
+	backup := &v1alpha1.RedisBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: redis.Namespace}, backup); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+
+		labels := labelsForRedis(redis.Name)
+		labels[finalBackupLabel] = redis.Name
+		// The backup is not owned by the Redis, so it outlives the instance.
+		backup = &v1alpha1.RedisBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: redis.Namespace, Labels: labels},
+			Spec: v1alpha1.RedisBackupSpec{
+				RedisName:      redis.Name,
+				Destination:    *redis.Spec.Backup.Destination.DeepCopy(),
+				DeletionPolicy: v1alpha1.BackupDeletionPolicyRetain,
+			},
+		}
+		logger.Info("Creating the final RedisBackup", "RedisBackup.Namespace", backup.Namespace, "RedisBackup.Name", backup.Name)
+		if err := r.Create(ctx, backup); err != nil {
+			return false, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FinalSnapshot", fmt.Sprintf("Created final backup %s", backup.Name))
+	}
+
+	condition := metav1.Condition{
+		Type:    conditionFinalSnapshot,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Snapshotting",
+		Message: fmt.Sprintf("Waiting for the final backup %s", backup.Name),
+	}
+	switch backup.Status.Phase {
+	case v1alpha1.BackupPhaseCompleted:
+		condition.Status, condition.Reason = metav1.ConditionTrue, "Completed"
+		condition.Message = fmt.Sprintf("Final backup %s stored at %s", backup.Name, backup.Status.Location)
+	case v1alpha1.BackupPhaseFailed:
+		condition.Reason = "Failed"
+		condition.Message = fmt.Sprintf("Final backup %s failed, delete it to retry or change spec.deletionPolicy to delete the Redis without it", backup.Name)
+	}
+	if err := r.setCondition(ctx, redis, condition); err != nil {
+		return false, err
+	}
+	return condition.Status == metav1.ConditionTrue, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// exporterContainerName is the name of the redis_exporter sidecar of the Redis pods.
+	exporterContainerName = "exporter"
+	// metricsPortName is the name of the exporter port on the pods and on the primary service.
+	metricsPortName = "metrics"
+	// defaultExporterImage mirrors the default of spec.monitoring.exporter.image.
+	defaultExporterImage = "oliver006/redis_exporter"
+
+	// exporterPasswordFileKey holds the password file of the exporter in the password secret while the
+	// password is rotated. It maps the address the exporter scrapes to the current password.
+	exporterPasswordFileKey = "exporter-password-file"
+	// exporterAuthVolumeName is the name of the volume projecting the password file into the exporter.
+	exporterAuthVolumeName = "exporter-auth"
+	// exporterAuthDir is the directory the password file is projected to.
+	exporterAuthDir = "/etc/redis-exporter"
+	// exporterReloadTimeout bounds the request asking an exporter to reload its password file.
+	exporterReloadTimeout = 5 * time.Second
+)
+
+// hasExporter reports whether the Redis pods run the redis_exporter sidecar.
+func hasExporter(redis *v1alpha1.Redis) bool {
+	return redis.Spec.Monitoring != nil && redis.Spec.Monitoring.Exporter != nil && redis.Spec.Monitoring.Exporter.Enabled
+}
+
+// addExporter adds the redis_exporter sidecar to a pod template. It scrapes the Redis container over
+// localhost and authenticates with the password secret, like the Redis container itself. Over TLS it
+// verifies the server against the CA, which the server certificate covers localhost for.
+//
+// Environment variables are only read when the pod starts, and rotating the password does not restart
+// the pods. With spec.passwordRotation the exporter therefore reads the password from the password file
+// the operator keeps in the password secret, and is asked to reload it before the old password is dropped.
+// The grace period of the rotation gives the kubelet time to refresh the projected file in the meantime.
+func addExporter(redis *v1alpha1.Redis, spec *corev1.PodSpec) {
+	exporter := redis.Spec.Monitoring.Exporter
+
+	args := []string{
+		"--redis.addr=" + exporterRedisAddr(redis),
+		fmt.Sprintf("--web.listen-address=:%d", v1alpha1.ExporterPort),
+	}
+	if rotatesPassword(redis) {
+		args = append(args, fmt.Sprintf("--redis.password-file=%s/%s", exporterAuthDir, exporterPasswordFileKey))
+	}
+	if hasTLS(redis) {
+		args = append(args, fmt.Sprintf("--tls-ca-cert-file=%s/%s", redisTLSDir, caCertKey))
+		if redis.Spec.TLS.AuthClients {
+			args = append(args,
+				fmt.Sprintf("--tls-client-cert-file=%s/%s", redisTLSDir, corev1.TLSCertKey),
+				fmt.Sprintf("--tls-client-key-file=%s/%s", redisTLSDir, corev1.TLSPrivateKeyKey),
+			)
+		}
+	}
+	args = append(args, exporter.ExtraArgs...)
+
+	// An image left empty, because the instance was stored without the CRD defaults, gets the same default.
+	image := exporter.Image
+	if image == "" {
+		image = defaultExporterImage
+	}
+	container := corev1.Container{
+		Name:      exporterContainerName,
+		Image:     image,
+		Args:      args,
+		Ports:     []corev1.ContainerPort{{ContainerPort: v1alpha1.ExporterPort, Name: metricsPortName}},
+		Resources: exporter.Resources,
+	}
+	if rotatesPassword(redis) {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: exporterAuthVolumeName, MountPath: exporterAuthDir, ReadOnly: true})
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: exporterAuthVolumeName,
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: redis.Spec.PasswordSecretName,
+				Items:      []corev1.KeyToPath{{Key: exporterPasswordFileKey, Path: exporterPasswordFileKey}},
+			}},
+		})
+	} else {
+		container.Env = []corev1.EnvVar{{
+			Name: "REDIS_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: redis.Spec.PasswordSecretName},
+					Key:                  v1alpha1.PasswordSecretKey(redis),
+				},
+			},
+		}}
+	}
+	if hasTLS(redis) {
+		// The Redis container already added the volume, so only the mount is needed.
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: tlsVolumeName, MountPath: redisTLSDir, ReadOnly: true})
+	}
+	spec.Containers = append(spec.Containers, container)
+}
+
+// exporterRedisAddr returns the address the exporter scrapes the Redis container at.
+func exporterRedisAddr(redis *v1alpha1.Redis) string {
+	scheme := "redis"
+	if hasTLS(redis) {
+		scheme = "rediss"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, *redis.Spec.Port)
+}
+
+// setExporterPasswordFile points the exporter password file in the password secret at the given password,
+// or removes it if the exporter does not read it. It reports whether the secret changed.
+func setExporterPasswordFile(redis *v1alpha1.Redis, secret *corev1.Secret, password string) (bool, error) {
+	if !hasExporter(redis) || !rotatesPassword(redis) {
+		_, ok := secret.Data[exporterPasswordFileKey]
+		delete(secret.Data, exporterPasswordFileKey)
+		return ok, nil
+	}
+	data, err := json.Marshal(map[string]string{exporterRedisAddr(redis): password})
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(secret.Data[exporterPasswordFileKey], data) {
+		return false, nil
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[exporterPasswordFileKey] = data
+	return true, nil
+}
+
+// reloadExporters asks the exporter of every running Redis pod to reload its password file, and reports
+// whether every exporter did.
+func (r *RedisReconciler) reloadExporters(ctx context.Context, redis *v1alpha1.Redis) bool {
+	logger := log.FromContext(ctx)
+
+	if !hasExporter(redis) {
+		return true
+	}
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		logger.Error(err, "Unable to list the pods to reload the exporters on")
+		return false
+	}
+
+	httpClient := &http.Client{Timeout: exporterReloadTimeout}
+	reloaded := true
+	for i := range pods {
+		pod := &pods[i]
+		if !containerRunning(pod, exporterContainerName) {
+			continue
+		}
+		if err := reloadExporter(ctx, httpClient, pod); err != nil {
+			logger.Error(err, "Unable to reload the exporter password file", "Pod.Name", pod.Name)
+			reloaded = false
+		}
+	}
+	return reloaded
+}
+
+// reloadExporter asks the exporter of a pod to reload its password file.
+func reloadExporter(ctx context.Context, httpClient *http.Client, pod *corev1.Pod) error {
+	url := fmt.Sprintf("http://%s:%d/-/reload", pod.Status.PodIP, v1alpha1.ExporterPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("reload returned %s", resp.Status)
+	}
+	return nil
+}
+
+// exporterServicePort returns the port of the primary service exposing the metrics of the exporter.
+func exporterServicePort() corev1.ServicePort {
+	return corev1.ServicePort{Name: metricsPortName, Port: v1alpha1.ExporterPort, TargetPort: intstr.FromString(metricsPortName)}
+}
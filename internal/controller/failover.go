@@ -0,0 +1,389 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// failoverAnnotation requests a manual failover to the named pod. It is removed once the failover is done.
+	failoverAnnotation = "redis.yazio.com/failover-to"
+	// failoverStartedAnnotation records when an asynchronous failover was started. It is removed together
+	// with failoverAnnotation.
+	failoverStartedAnnotation = "redis.yazio.com/failover-started"
+
+	// failoverPauseMillis bounds how long writes are paused on the old primary during a failover.
+	failoverPauseMillis = 10000
+	// failoverSyncTimeout bounds how long the target may take to catch up with the old primary.
+	failoverSyncTimeout = 5 * time.Second
+	// clusterFailoverTimeout bounds how long a replica may take to take over its primary in cluster mode.
+	clusterFailoverTimeout = time.Minute
+	// defaultReplicaPriority is the replica-priority Redis starts with.
+	defaultReplicaPriority = 100
+)
+
+// reconcileFailover performs the manual failover requested with the failover-to annotation and removes
+// the annotation once it is done. It reports whether the failover is still in progress.
+func (r *RedisReconciler) reconcileFailover(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	targetName, ok := redis.Annotations[failoverAnnotation]
+	if !ok {
+		if _, started := redis.Annotations[failoverStartedAnnotation]; started {
+			// The request was withdrawn while the failover was in progress.
+			return false, r.clearFailover(ctx, redis)
+		}
+		return false, nil
+	}
+	if !isReplicated(redis) && !isClustered(redis) {
+		return false, r.rejectFailover(ctx, redis, "Manual failover requires replication or cluster mode")
+	}
+
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+	var target *corev1.Pod
+	for i := range pods {
+		if pods[i].Name == targetName && podRunning(&pods[i]) {
+			target = &pods[i]
+		}
+	}
+	if target == nil {
+		return false, r.rejectFailover(ctx, redis, fmt.Sprintf("Pod %s is not a running Redis pod of this instance", targetName))
+	}
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case isClustered(redis):
+		return r.clusterFailover(ctx, redis, target, password)
+	case hasSentinel(redis):
+		return r.sentinelFailover(ctx, redis, pods, target, password)
+	default:
+		return false, r.replicationFailover(ctx, redis, pods, target, password)
+	}
+}
+
+// replicationFailover promotes the target pod and repoints every other pod at it. Writes are paused on the
+// old primary until the target has caught up, so no acknowledged write is lost. If the target does not catch
+// up in time, writes are resumed and the request is dropped instead of pausing the primary on every retry.
+// Writes are only resumed once the old primary is demoted or at least labelled replica, so the primary
+// service never selects two writable pods. Otherwise the pause expires and the failover is retried.
+func (r *RedisReconciler) replicationFailover(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, target *corev1.Pod, password string) error {
+	promoted := false
+	if redis.Status.Primary != target.Name {
+		targetClient := redisClientForPod(redis, target, password, r.clientTLSConfig(redis))
+		defer func() { _ = targetClient.Close() }()
+
+		var oldPrimary *corev1.Pod
+		for i := range pods {
+			if pods[i].Name == redis.Status.Primary && podRunning(&pods[i]) {
+				oldPrimary = &pods[i]
+			}
+		}
+		if oldPrimary != nil {
+			oldClient := redisClientForPod(redis, oldPrimary, password, r.clientTLSConfig(redis))
+			defer func() { _ = oldClient.Close() }()
+
+			if err := oldClient.Do(ctx, "CLIENT", "PAUSE", failoverPauseMillis, "WRITE").Err(); err != nil {
+				return err
+			}
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "PausedWrites", fmt.Sprintf("Paused writes on pod %s", oldPrimary.Name))
+			if err := waitForReplicaSync(ctx, oldClient, targetClient); err != nil {
+				_ = oldClient.Do(ctx, "CLIENT", "UNPAUSE").Err()
+				r.Recorder.Event(redis, corev1.EventTypeWarning, "FailoverFailed", fmt.Sprintf("Pod %s did not catch up with the primary, writes were resumed and the failover was given up: %v", target.Name, err))
+				return r.clearFailover(ctx, redis)
+			}
+			// Only resume writes on the old primary once it no longer receives them from the service.
+			defer func() {
+				if oldPrimary.Labels[roleLabel] != rolePrimary {
+					_ = oldClient.Do(ctx, "CLIENT", "UNPAUSE").Err()
+				}
+			}()
+		}
+
+		if err := targetClient.Do(ctx, "REPLICAOF", "NO", "ONE").Err(); err != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "PromotedPrimary", fmt.Sprintf("Promoted pod %s to primary", target.Name))
+
+		base := redis.DeepCopy()
+		redis.Status.Primary = target.Name
+		if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "PrimaryElected", fmt.Sprintf("Pod %s is the replication primary", target.Name))
+		promoted = true
+	}
+
+	relabelled, err := r.demoteToReplicas(ctx, redis, pods, target, password)
+	if err != nil {
+		// The annotation is kept, so the pods are demoted again on the next reconcile.
+		return err
+	}
+
+	if promoted || relabelled {
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverComplete", fmt.Sprintf("Failed over to pod %s", target.Name))
+	} else {
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverSkipped", fmt.Sprintf("Pod %s already is the primary", target.Name))
+	}
+	return r.clearFailover(ctx, redis)
+}
+
+// demoteToReplicas repoints every pod but the primary at it and labels it replica. A pod that cannot be
+// repointed now is still labelled replica, which fences it off the primary service until the regular
+// replication reconcile repoints it. It reports whether any pod was relabelled.
+func (r *RedisReconciler) demoteToReplicas(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, primary *corev1.Pod, password string) (bool, error) {
+	relabelled := false
+	for i := range pods {
+		pod := &pods[i]
+		role := roleReplica
+		if pod.Name == primary.Name {
+			role = rolePrimary
+		} else if podRunning(pod) {
+			if err := r.configureRole(ctx, redis, pod, primary.Name, role, password, false); err != nil {
+				log.FromContext(ctx).Error(err, "Unable to repoint replica, fencing it off the primary service", "Pod.Name", pod.Name)
+			}
+		}
+		if pod.Labels[roleLabel] != role {
+			relabelled = true
+		}
+		if err := r.setPodRole(ctx, pod, role); err != nil {
+			return relabelled, err
+		}
+	}
+	return relabelled, nil
+}
+
+// sentinelFailover lets Sentinel fail over to the target pod. Every other replica is made ineligible for
+// promotion first, since SENTINEL FAILOVER cannot name the replica to promote, and gets its configured
+// replica-priority back when the request is cleared. Sentinel fails over asynchronously, so it reports
+// whether the failover is still in progress. The request is given up after sentinelFailoverTimeout.
+func (r *RedisReconciler) sentinelFailover(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, target *corev1.Pod, password string) (bool, error) {
+	current, err := r.sentinelPrimary(ctx, redis, pods, password)
+	if err != nil {
+		return false, err
+	}
+
+	started, err := time.Parse(time.RFC3339, redis.Annotations[failoverStartedAnnotation])
+	requested := err == nil
+	switch {
+	case current != nil && current.Name == target.Name && requested:
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverComplete", fmt.Sprintf("Sentinel failed over to pod %s", target.Name))
+		return false, r.clearFailover(ctx, redis)
+	case current != nil && current.Name == target.Name:
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverSkipped", fmt.Sprintf("Pod %s already is the primary", target.Name))
+		return false, r.clearFailover(ctx, redis)
+	case requested && time.Since(started) > sentinelFailoverTimeout(redis):
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "FailoverFailed", fmt.Sprintf("Sentinel did not fail over to pod %s within %s", target.Name, sentinelFailoverTimeout(redis)))
+		return false, r.clearFailover(ctx, redis)
+	case !requested:
+		// The start is recorded before the priorities are lowered, so a withdrawn request restores them.
+		patch := client.MergeFrom(redis.DeepCopy())
+		redis.Annotations[failoverStartedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err := r.Patch(ctx, redis, patch); err != nil {
+			return false, err
+		}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		priority := replicaPriority(redis)
+		if pod.Name != target.Name {
+			priority = "0"
+		}
+		rdb := redisClientForPod(redis, pod, password, r.clientTLSConfig(redis))
+		err := rdb.ConfigSet(ctx, "replica-priority", priority).Err()
+		_ = rdb.Close()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	sentinelPods := &corev1.PodList{}
+	if err := r.List(ctx, sentinelPods, client.InNamespace(redis.Namespace), client.MatchingLabels(sentinelLabels(redis.Name))); err != nil {
+		return false, err
+	}
+	for i := range sentinelPods.Items {
+		sentinelPod := &sentinelPods.Items[i]
+		if !containerRunning(sentinelPod, "sentinel") {
+			continue
+		}
+		sentinel := newSentinelClient(sentinelPod.Status.PodIP, password, r.clientTLSConfig(redis))
+		err := sentinel.Failover(ctx, redis.Name).Err()
+		_ = sentinel.Close()
+		switch {
+		case err == nil:
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverRequested", fmt.Sprintf("Asked Sentinel to fail over to pod %s", target.Name))
+		case strings.HasPrefix(err.Error(), "INPROG"):
+			// The failover requested earlier is still running.
+		default:
+			return false, err
+		}
+		return true, nil
+	}
+	return true, nil
+}
+
+// clusterFailover asks the target replica to take over its primary with CLUSTER FAILOVER, which pauses
+// the primary's clients until the replica has caught up. The takeover happens asynchronously, so the
+// request is kept until the target reports the primary role, and given up after clusterFailoverTimeout.
+// It reports whether the failover is still in progress.
+func (r *RedisReconciler) clusterFailover(ctx context.Context, redis *v1alpha1.Redis, target *corev1.Pod, password string) (bool, error) {
+	rdb := redisClientForPod(redis, target, password, r.clientTLSConfig(redis))
+	defer func() { _ = rdb.Close() }()
+
+	info, err := redisInfo(ctx, rdb, "replication")
+	if err != nil {
+		return false, err
+	}
+	started, err := time.Parse(time.RFC3339, redis.Annotations[failoverStartedAnnotation])
+	requested := err == nil
+	switch {
+	case info["role"] == "master" && requested:
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverComplete", fmt.Sprintf("Pod %s took over its primary", target.Name))
+		return false, r.clearFailover(ctx, redis)
+	case info["role"] == "master":
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverSkipped", fmt.Sprintf("Pod %s already is a primary", target.Name))
+		return false, r.clearFailover(ctx, redis)
+	case requested && time.Since(started) > clusterFailoverTimeout:
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "FailoverFailed", fmt.Sprintf("Pod %s did not take over its primary within %s", target.Name, clusterFailoverTimeout))
+		return false, r.clearFailover(ctx, redis)
+	case requested:
+		return true, nil
+	}
+
+	if err := rdb.ClusterFailover(ctx).Err(); err != nil {
+		return false, err
+	}
+	patch := client.MergeFrom(redis.DeepCopy())
+	redis.Annotations[failoverStartedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := r.Patch(ctx, redis, patch); err != nil {
+		return false, err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "FailoverRequested", fmt.Sprintf("Asked pod %s to take over its primary", target.Name))
+	return true, nil
+}
+
+// waitForReplicaSync waits until the replica has processed the full replication stream of the primary.
+func waitForReplicaSync(ctx context.Context, primary *goredis.Client, replica *goredis.Client) error {
+	primaryInfo, err := redisInfo(ctx, primary, "replication")
+	if err != nil {
+		return err
+	}
+	primaryOffset, err := strconv.ParseInt(primaryInfo["master_repl_offset"], 10, 64)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(failoverSyncTimeout)
+	for {
+		replicaInfo, err := redisInfo(ctx, replica, "replication")
+		if err != nil {
+			return err
+		}
+		replicaOffset, _ := strconv.ParseInt(replicaInfo["slave_repl_offset"], 10, 64)
+		if replicaOffset >= primaryOffset {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("replica at offset %d, primary at %d", replicaOffset, primaryOffset)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// restoreReplicaPriority gives every running pod the replica-priority set in spec.config back, which
+// sentinelFailover lowered to steer the failover.
+func (r *RedisReconciler) restoreReplicaPriority(ctx context.Context, redis *v1alpha1.Redis) error {
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return err
+	}
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		return err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		rdb := redisClientForPod(redis, pod, password, r.clientTLSConfig(redis))
+		err := rdb.ConfigSet(ctx, "replica-priority", replicaPriority(redis)).Err()
+		_ = rdb.Close()
+		if err != nil {
+			return fmt.Errorf("restoring replica-priority on pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// sentinelFailoverTimeout returns how long Sentinel may take to fail over. Sentinel does not retry a failover
+// of the same primary within twice its failover-timeout, so a request still pending by then was dropped.
+func sentinelFailoverTimeout(redis *v1alpha1.Redis) time.Duration {
+	if redis.Spec.Sentinel.FailoverTimeout <= 0 {
+		return clusterFailoverTimeout
+	}
+	return 2 * time.Duration(redis.Spec.Sentinel.FailoverTimeout) * time.Millisecond
+}
+
+// replicaPriority returns the replica-priority set in spec.config, or the one Redis starts with.
+func replicaPriority(redis *v1alpha1.Redis) string {
+	for key, value := range redis.Spec.Config {
+		if strings.EqualFold(key, "replica-priority") || strings.EqualFold(key, "slave-priority") {
+			return value
+		}
+	}
+	return strconv.Itoa(defaultReplicaPriority)
+}
+
+// rejectFailover reports a failover request that cannot be performed and removes it.
+func (r *RedisReconciler) rejectFailover(ctx context.Context, redis *v1alpha1.Redis, message string) error {
+	r.Recorder.Event(redis, corev1.EventTypeWarning, "FailoverRejected", message)
+	return r.clearFailover(ctx, redis)
+}
+
+// clearFailover removes the failover-to annotation and the start time of the failover. With Sentinel the
+// replicas first get their configured replica-priority back, whichever way the request ended.
+func (r *RedisReconciler) clearFailover(ctx context.Context, redis *v1alpha1.Redis) error {
+	if hasSentinel(redis) {
+		if err := r.restoreReplicaPriority(ctx, redis); err != nil {
+			return err
+		}
+	}
+	patch := client.MergeFrom(redis.DeepCopy())
+	delete(redis.Annotations, failoverAnnotation)
+	delete(redis.Annotations, failoverStartedAnnotation)
+	return r.Patch(ctx, redis, patch)
+}
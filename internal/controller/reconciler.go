@@ -22,14 +22,18 @@ import (
 	"fmt"
 	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -37,6 +41,23 @@ import (
 	"github.com/pehlicd/redis-operator/api/v1alpha1"
 )
 
+const (
+	// dataVolumeName is the name of the Redis data volume and of its claim template.
+	dataVolumeName = "data"
+	// redisDataDir is the directory the Redis data volume is mounted at.
+	redisDataDir = "/data"
+	// redisFSGroup is the group of the bitnami/redis user, uid 1001. Freshly provisioned volumes are owned by
+	// root, so the data volume is handed to this group for Redis to write its RDB and AOF files.
+	redisFSGroup = int64(1001)
+
+	// roleLabel is the pod label carrying the replication role of a Redis pod.
+	roleLabel = "redis.yazio.com/role"
+	// rolePrimary marks the pod that accepts writes.
+	rolePrimary = "primary"
+	// roleReplica marks pods replicating the primary.
+	roleReplica = "replica"
+)
+
 // reconcileSecret ensures the secret for the Redis instance exists.
 func (r *RedisReconciler) reconcileSecret(ctx context.Context, redis *v1alpha1.Redis) (*corev1.Secret, error) {
 	logger := log.FromContext(ctx)
@@ -54,7 +75,7 @@ func (r *RedisReconciler) reconcileSecret(ctx context.Context, redis *v1alpha1.R
 			newSecret := &corev1.Secret{
 				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: redis.Namespace},
 				Type:       corev1.SecretTypeOpaque,
-				StringData: map[string]string{"password": password},
+				StringData: map[string]string{v1alpha1.PasswordSecretKey(redis): password},
 			}
 			if err := ctrl.SetControllerReference(redis, newSecret, r.Scheme); err != nil {
 				return nil, err
@@ -71,10 +92,55 @@ func (r *RedisReconciler) reconcileSecret(ctx context.Context, redis *v1alpha1.R
 	return secret, nil
 }
 
-// reconcileService ensures the service for the Redis instance is up-to-date.
+// reconcileService ensures the services for the Redis instance are up-to-date.
+// Next to the primary service this manages the optional read service of replicated instances.
 func (r *RedisReconciler) reconcileService(ctx context.Context, redis *v1alpha1.Redis) (*corev1.Service, error) {
+	svc, err := r.ensureService(ctx, redis, r.serviceForRedis(redis))
+	if err != nil {
+		return nil, err
+	}
+
+	readSvc := r.readServiceForRedis(redis)
+	if readSvc != nil {
+		if _, err := r.ensureService(ctx, redis, readSvc); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.deleteStaleReadServices(ctx, redis, readSvc); err != nil {
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+// deleteStaleReadServices removes read services that were renamed or are no longer configured.
+func (r *RedisReconciler) deleteStaleReadServices(ctx context.Context, redis *v1alpha1.Redis, desired *corev1.Service) error {
+	serviceList := &corev1.ServiceList{}
+	if err := r.List(ctx, serviceList, client.InNamespace(redis.Namespace), client.MatchingLabels(readServiceLabels(redis.Name))); err != nil {
+		return err
+	}
+
+	for i := range serviceList.Items {
+		svc := &serviceList.Items[i]
+		if (desired != nil && svc.Name == desired.Name) || !metav1.IsControlledBy(svc, redis) {
+			continue
+		}
+		if err := r.Delete(ctx, svc); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "DeletedService", fmt.Sprintf("Deleted read service %s", svc.Name))
+	}
+	return nil
+}
+
+// reconcileHeadlessService ensures the headless service governing the Redis StatefulSet is up-to-date.
+func (r *RedisReconciler) reconcileHeadlessService(ctx context.Context, redis *v1alpha1.Redis) (*corev1.Service, error) {
+	return r.ensureService(ctx, redis, r.headlessServiceForRedis(redis))
+}
+
+// ensureService creates the desired service or patches the fields we manage on the existing one.
+func (r *RedisReconciler) ensureService(ctx context.Context, redis *v1alpha1.Redis, desiredSvc *corev1.Service) (*corev1.Service, error) {
 	logger := log.FromContext(ctx)
-	desiredSvc := r.serviceForRedis(redis)
 	foundSvc := &corev1.Service{}
 
 	err := r.Get(ctx, types.NamespacedName{Name: desiredSvc.Name, Namespace: redis.Namespace}, foundSvc)
@@ -91,17 +157,15 @@ func (r *RedisReconciler) reconcileService(ctx context.Context, redis *v1alpha1.
 		return nil, err
 	}
 
-	patch := client.MergeFrom(desiredSvc)
+	patch := client.MergeFrom(foundSvc.DeepCopy())
 	needsUpdate := false
 	if !reflect.DeepEqual(foundSvc.Spec.Type, desiredSvc.Spec.Type) {
 		foundSvc.Spec.Type = desiredSvc.Spec.Type
 		needsUpdate = true
 	}
-	for i, port := range foundSvc.Spec.Ports {
-		if port.Port != desiredSvc.Spec.Ports[i].Port || port.Name != desiredSvc.Spec.Ports[i].Name {
-			foundSvc.Spec.Ports[i] = desiredSvc.Spec.Ports[i]
-			needsUpdate = true
-		}
+	if !portsMatch(foundSvc.Spec.Ports, desiredSvc.Spec.Ports) {
+		foundSvc.Spec.Ports = desiredSvc.Spec.Ports
+		needsUpdate = true
 	}
 	if !reflect.DeepEqual(foundSvc.Spec.Selector, desiredSvc.Spec.Selector) {
 		foundSvc.Spec.Selector = desiredSvc.Spec.Selector
@@ -118,10 +182,31 @@ func (r *RedisReconciler) reconcileService(ctx context.Context, redis *v1alpha1.
 	return foundSvc, nil
 }
 
+// portsMatch compares the service ports we manage by name, port and target port.
+func portsMatch(found []corev1.ServicePort, desired []corev1.ServicePort) bool {
+	if len(found) != len(desired) {
+		return false
+	}
+	for i := range found {
+		if found[i].Name != desired[i].Name || found[i].Port != desired[i].Port || found[i].TargetPort != desired[i].TargetPort {
+			return false
+		}
+	}
+	return true
+}
+
 // reconcileDeployment ensures the deployment for the Redis instance is up-to-date.
-func (r *RedisReconciler) reconcileDeployment(ctx context.Context, redis *v1alpha1.Redis) (*appsv1.Deployment, error) {
+func (r *RedisReconciler) reconcileDeployment(ctx context.Context, redis *v1alpha1.Redis, restore *restoreSource) (*appsv1.Deployment, error) {
+	checksum, err := r.podChecksum(ctx, redis)
+	if err != nil {
+		return nil, err
+	}
+	return r.ensureDeployment(ctx, redis, r.deploymentForRedis(redis, checksum, restore))
+}
+
+// ensureDeployment creates the desired deployment or patches the replicas and pod template of the existing one.
+func (r *RedisReconciler) ensureDeployment(ctx context.Context, redis *v1alpha1.Redis, desiredDep *appsv1.Deployment) (*appsv1.Deployment, error) {
 	logger := log.FromContext(ctx)
-	desiredDep := r.deploymentForRedis(redis)
 	foundDep := &appsv1.Deployment{}
 
 	err := r.Get(ctx, types.NamespacedName{Name: desiredDep.Name, Namespace: redis.Namespace}, foundDep)
@@ -162,6 +247,175 @@ func (r *RedisReconciler) reconcileDeployment(ctx context.Context, redis *v1alph
 	return foundDep, nil
 }
 
+// ensureConfigMap creates the desired config map or replaces the data of the existing one.
+func (r *RedisReconciler) ensureConfigMap(ctx context.Context, redis *v1alpha1.Redis, desiredCm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	logger := log.FromContext(ctx)
+	foundCm := &corev1.ConfigMap{}
+
+	err := r.Get(ctx, types.NamespacedName{Name: desiredCm.Name, Namespace: redis.Namespace}, foundCm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", desiredCm.Namespace, "ConfigMap.Name", desiredCm.Name)
+			if err = r.Create(ctx, desiredCm); err != nil {
+				return nil, err
+			}
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "CreatedConfigMap", fmt.Sprintf("Created config map %s", desiredCm.Name))
+			return desiredCm, nil
+		}
+		return nil, err
+	}
+
+	if reflect.DeepEqual(foundCm.Data, desiredCm.Data) {
+		return foundCm, nil
+	}
+	patch := client.MergeFrom(foundCm.DeepCopy())
+	foundCm.Data = desiredCm.Data
+	if err := r.Patch(ctx, foundCm, patch); err != nil {
+		return nil, err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "UpdatedConfigMap", fmt.Sprintf("Updated config map %s", desiredCm.Name))
+	return foundCm, nil
+}
+
+// deleteIfOwned deletes an object controlled by the Redis instance if it exists.
+func (r *RedisReconciler) deleteIfOwned(ctx context.Context, redis *v1alpha1.Redis, obj client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, redis) {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("Deleted object no longer in spec", "Name", obj.GetName())
+	return nil
+}
+
+// reconcileStatefulSet ensures the statefulset for the Redis instance is up-to-date.
+func (r *RedisReconciler) reconcileStatefulSet(ctx context.Context, redis *v1alpha1.Redis, restore *restoreSource) (*appsv1.StatefulSet, error) {
+	logger := log.FromContext(ctx)
+	checksum, err := r.podChecksum(ctx, redis)
+	if err != nil {
+		return nil, err
+	}
+	desiredSts := r.statefulSetForRedis(redis, checksum, restore)
+	foundSts := &appsv1.StatefulSet{}
+
+	err = r.Get(ctx, types.NamespacedName{Name: desiredSts.Name, Namespace: redis.Namespace}, foundSts)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("Creating a new StatefulSet", "StatefulSet.Namespace", desiredSts.Namespace, "StatefulSet.Name", desiredSts.Name)
+			if err = r.Create(ctx, desiredSts); err != nil {
+				return nil, err
+			}
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "CreatedStatefulSet", fmt.Sprintf("Created statefulset %s", desiredSts.Name))
+			return desiredSts, nil
+		}
+		return nil, err
+	}
+
+	// Volume claim templates are immutable, so adding or removing persistence requires a new StatefulSet.
+	// Pods are orphaned rather than deleted and get adopted and rolled by the replacement.
+	if !claimTemplateNamesMatch(foundSts.Spec.VolumeClaimTemplates, desiredSts.Spec.VolumeClaimTemplates) {
+		logger.Info("Volume claim templates changed, recreating StatefulSet", "StatefulSet.Name", foundSts.Name)
+		if err = r.Delete(ctx, foundSts, client.PropagationPolicy(metav1.DeletePropagationOrphan)); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "RecreatingStatefulSet", fmt.Sprintf("Recreating statefulset %s to change its volume claim templates", foundSts.Name))
+		return foundSts, nil
+	}
+
+	needsUpdate := false
+	patch := client.MergeFrom(foundSts.DeepCopy())
+	if !replicasMatch(foundSts.Spec.Replicas, desiredSts.Spec.Replicas) {
+		foundSts.Spec.Replicas = desiredSts.Spec.Replicas
+		needsUpdate = true
+		logger.Info("Replica count changed", "From", foundSts.Spec.Replicas, "To", desiredSts.Spec.Replicas)
+	}
+
+	if !templatesMatch(&foundSts.Spec.Template, &desiredSts.Spec.Template) {
+		foundSts.Spec.Template = desiredSts.Spec.Template
+		needsUpdate = true
+		logger.Info("Pod template changed")
+	}
+
+	if needsUpdate {
+		logger.Info("Updating StatefulSet", "StatefulSet.Name", foundSts.Name)
+		if err = r.Patch(ctx, foundSts, patch); err != nil {
+			return nil, err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "UpdatedStatefulSet", "StatefulSet spec updated.")
+	}
+
+	return foundSts, nil
+}
+
+// deleteStaleWorkload removes the workload and services left behind when spec.workloadKind is switched.
+func (r *RedisReconciler) deleteStaleWorkload(ctx context.Context, redis *v1alpha1.Redis) error {
+	stale := []client.Object{
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: redis.Name, Namespace: redis.Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: headlessServiceName(redis), Namespace: redis.Namespace}},
+	}
+	if isStatefulSet(redis) {
+		stale = []client.Object{&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: redis.Name, Namespace: redis.Namespace}}}
+	}
+
+	for _, obj := range stale {
+		if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !metav1.IsControlledBy(obj, redis) {
+			continue
+		}
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Deleted stale workload object", "Name", obj.GetName())
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "DeletedStaleWorkload", fmt.Sprintf("Deleted %s after workload kind change", obj.GetName()))
+	}
+
+	return nil
+}
+
+// claimTemplateNamesMatch reports whether two StatefulSets declare the same volume claim templates by name.
+func claimTemplateNamesMatch(found []corev1.PersistentVolumeClaim, desired []corev1.PersistentVolumeClaim) bool {
+	if len(found) != len(desired) {
+		return false
+	}
+	for i := range found {
+		if found[i].Name != desired[i].Name {
+			return false
+		}
+	}
+	return true
+}
+
+// storageDrift compares the data claim template of a StatefulSet with the persistence spec.
+// Claim templates are immutable, so a changed storage class or size is only reported, never patched.
+func storageDrift(found *appsv1.StatefulSet, redis *v1alpha1.Redis) (reason string, message string) {
+	desired := volumeClaimTemplatesForRedis(redis)
+	if len(desired) == 0 || len(found.Spec.VolumeClaimTemplates) == 0 {
+		return "", ""
+	}
+	foundSpec := found.Spec.VolumeClaimTemplates[0].Spec
+	desiredSpec := desired[0].Spec
+
+	if ptr.Deref(foundSpec.StorageClassName, "") != ptr.Deref(desiredSpec.StorageClassName, "") {
+		return "StorageClassChanged", fmt.Sprintf("Storage class changed from %q to %q, existing volumes keep the old class",
+			ptr.Deref(foundSpec.StorageClassName, ""), ptr.Deref(desiredSpec.StorageClassName, ""))
+	}
+	foundSize := foundSpec.Resources.Requests[corev1.ResourceStorage]
+	desiredSize := desiredSpec.Resources.Requests[corev1.ResourceStorage]
+	if foundSize.Cmp(desiredSize) != 0 {
+		return "StorageSizeChanged", fmt.Sprintf("Storage size changed from %s to %s", foundSize.String(), desiredSize.String())
+	}
+	return "", ""
+}
+
 // replicasMatch compares the replica counts of two deployments.
 func replicasMatch(foundReplicas *int32, desiredReplicas *int32) bool {
 	if foundReplicas == nil && desiredReplicas == nil {
@@ -173,70 +427,249 @@ func replicasMatch(foundReplicas *int32, desiredReplicas *int32) bool {
 	return *foundReplicas == *desiredReplicas
 }
 
-// templatesMatch semantically compares two PodTemplateSpecs for the fields we manage.
+// templatesMatch semantically compares two PodTemplateSpecs for the fields we manage. Containers are
+// matched by name, so a sidecar added, removed or changed is detected like a change of the Redis container.
 func templatesMatch(found *corev1.PodTemplateSpec, desired *corev1.PodTemplateSpec) bool {
-	if found.Spec.Containers == nil && len(found.Spec.Containers) < 1 {
+	if len(found.Spec.Containers) != len(desired.Spec.Containers) {
 		return false
 	}
+	for i := range desired.Spec.Containers {
+		desiredContainer := &desired.Spec.Containers[i]
+		foundContainer := findContainer(found.Spec.Containers, desiredContainer.Name)
+		if foundContainer == nil || !containersMatch(foundContainer, desiredContainer) {
+			return false
+		}
+	}
+	if len(found.Spec.InitContainers) != len(desired.Spec.InitContainers) {
+		return false
+	}
+	if !reflect.DeepEqual(fsGroup(found), fsGroup(desired)) {
+		return false
+	}
+	for _, annotation := range []string{configHashAnnotation, checksumAnnotation} {
+		if found.Annotations[annotation] != desired.Annotations[annotation] {
+			return false
+		}
+	}
+	return true
+}
 
-	foundContainer := &found.Spec.Containers[0]
-	desiredContainer := &desired.Spec.Containers[0]
-
-	if foundContainer.Image != desiredContainer.Image {
+// containersMatch compares two containers of the same name for the fields we manage.
+func containersMatch(found *corev1.Container, desired *corev1.Container) bool {
+	if found.Image != desired.Image {
 		return false
 	}
-	if !reflect.DeepEqual(foundContainer.Resources, desiredContainer.Resources) {
+	if !reflect.DeepEqual(found.Resources, desired.Resources) {
 		return false
 	}
-	if foundContainer.LivenessProbe == nil || foundContainer.ReadinessProbe == nil {
+	// The API server defaults the probe fields, so only the presence of the probes is compared.
+	if (found.LivenessProbe == nil) != (desired.LivenessProbe == nil) || (found.ReadinessProbe == nil) != (desired.ReadinessProbe == nil) {
 		return false
 	}
-	if !reflect.DeepEqual(foundContainer.Env, desiredContainer.Env) {
+	if !reflect.DeepEqual(found.Env, desired.Env) {
 		return false
 	}
+	if !reflect.DeepEqual(found.Command, desired.Command) || !reflect.DeepEqual(found.Args, desired.Args) {
+		return false
+	}
+	if !containerPortsMatch(found.Ports, desired.Ports) {
+		return false
+	}
+	return volumeMountsMatch(found.VolumeMounts, desired.VolumeMounts)
+}
+
+// fsGroup returns the fsGroup of a pod template, or nil. The API server defaults an unset security context
+// to an empty one, so only the field we manage is compared.
+func fsGroup(template *corev1.PodTemplateSpec) *int64 {
+	if template.Spec.SecurityContext == nil {
+		return nil
+	}
+	return template.Spec.SecurityContext.FSGroup
+}
+
+// findContainer returns the container of the given name, or nil.
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// containerPortsMatch compares the ports of two containers by name and container port.
+func containerPortsMatch(found []corev1.ContainerPort, desired []corev1.ContainerPort) bool {
+	if len(found) != len(desired) {
+		return false
+	}
+	for i := range found {
+		if found[i].Name != desired[i].Name || found[i].ContainerPort != desired[i].ContainerPort {
+			return false
+		}
+	}
+	return true
+}
+
+// volumeMountsMatch compares the volume mounts of two containers by name and mount path.
+func volumeMountsMatch(found []corev1.VolumeMount, desired []corev1.VolumeMount) bool {
+	if len(found) != len(desired) {
+		return false
+	}
+	for i := range found {
+		if found[i].Name != desired[i].Name || found[i].MountPath != desired[i].MountPath {
+			return false
+		}
+	}
 	return true
 }
 
 // updateStatus updates the status subresource of the Redis CR using a patch.
-func (r *RedisReconciler) updateStatus(ctx context.Context, redis *v1alpha1.Redis, deployment *appsv1.Deployment) error {
+func (r *RedisReconciler) updateStatus(ctx context.Context, redis *v1alpha1.Redis, workload client.Object) error {
 	statusCopy := redis.DeepCopy()
 
 	statusCopy.Status.PasswordSecretName = redis.Spec.PasswordSecretName
-	desiredReplicas := *redis.Spec.Replicas
+	statusCopy.Status.PrimaryEndpoint = serviceEndpoint(redis.Spec.Service.Name, redis.Namespace, *redis.Spec.Service.Port)
+	statusCopy.Status.ReadEndpoint = ""
+	if readSvc := r.readServiceForRedis(redis); readSvc != nil {
+		statusCopy.Status.ReadEndpoint = serviceEndpoint(readSvc.Name, redis.Namespace, readSvc.Spec.Ports[0].Port)
+	}
+	statusCopy.Status.Selector = labels.SelectorFromSet(labelsForRedis(redis.Name)).String()
+	desiredReplicas := *redisReplicas(redis)
+
+	// Add a nil check for the workload to prevent panics early in the reconciliation.
+	kind := "Deployment"
+	availableReplicas := int32(-1)
+	rolling := false
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		if w != nil {
+			availableReplicas = w.Status.AvailableReplicas
+			statusCopy.Status.Replicas = w.Status.Replicas
+			statusCopy.Status.ReadyReplicas = w.Status.ReadyReplicas
+			rolling = w.Status.ObservedGeneration < w.Generation || w.Status.UpdatedReplicas < desiredReplicas
+		}
+	case *appsv1.StatefulSet:
+		kind = "StatefulSet"
+		if w != nil {
+			availableReplicas = w.Status.AvailableReplicas
+			statusCopy.Status.Replicas = w.Status.Replicas
+			statusCopy.Status.ReadyReplicas = w.Status.ReadyReplicas
+			rolling = w.Status.ObservedGeneration < w.Generation || w.Status.UpdatedReplicas < desiredReplicas
+		}
+	}
 
-	// Add a nil check for the deployment to prevent panics early in the reconciliation.
-	if deployment != nil && deployment.Status.AvailableReplicas == desiredReplicas {
+	if availableReplicas == desiredReplicas {
 		meta.SetStatusCondition(&statusCopy.Status.Conditions, metav1.Condition{
-			Type:               "Available",
+			Type:               conditionAvailable,
 			Status:             metav1.ConditionTrue,
-			Reason:             "DeploymentAvailable",
-			Message:            "Redis deployment is fully available",
+			Reason:             kind + "Available",
+			Message:            fmt.Sprintf("Redis %s is fully available", strings.ToLower(kind)),
 			ObservedGeneration: redis.Generation,
 		})
 	} else {
 		meta.SetStatusCondition(&statusCopy.Status.Conditions, metav1.Condition{
-			Type:               "Available",
+			Type:               conditionAvailable,
 			Status:             metav1.ConditionFalse,
 			Reason:             "Reconciling",
-			Message:            "Redis deployment is not yet fully available",
+			Message:            fmt.Sprintf("Redis %s is not yet fully available", strings.ToLower(kind)),
 			ObservedGeneration: redis.Generation,
 		})
 	}
+	statusCopy.Status.Phase = redisPhase(statusCopy.Status.ReadyReplicas, desiredReplicas, rolling)
+	statusCopy.Status.ObservedGeneration = redis.Generation
+
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return err
+	}
+	statusCopy.Status.Pods = podStatuses(redis, pods)
+	if version := r.runningVersion(ctx, redis, pods); version != "" {
+		statusCopy.Status.Version = version
+	}
+
+	r.setStorageCondition(statusCopy, workload)
 
 	patch := client.MergeFrom(redis)
 
 	return r.Status().Patch(ctx, statusCopy, patch)
 }
 
+// setStorageCondition surfaces drift between the persistence spec and the StatefulSet claim templates.
+func (r *RedisReconciler) setStorageCondition(redis *v1alpha1.Redis, workload client.Object) {
+	statefulSet, ok := workload.(*appsv1.StatefulSet)
+	if redis.Spec.Persistence == nil || !ok || statefulSet == nil {
+		meta.RemoveStatusCondition(&redis.Status.Conditions, conditionStorageSynced)
+		return
+	}
+
+	reason, message := storageDrift(statefulSet, redis)
+	if reason != "" {
+		if !meta.IsStatusConditionPresentAndEqual(redis.Status.Conditions, conditionStorageSynced, metav1.ConditionFalse) {
+			r.Recorder.Event(redis, corev1.EventTypeWarning, reason, message)
+		}
+		meta.SetStatusCondition(&redis.Status.Conditions, metav1.Condition{
+			Type:               conditionStorageSynced,
+			Status:             metav1.ConditionFalse,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: redis.Generation,
+		})
+		return
+	}
+
+	meta.SetStatusCondition(&redis.Status.Conditions, metav1.Condition{
+		Type:               conditionStorageSynced,
+		Status:             metav1.ConditionTrue,
+		Reason:             "StorageMatchesSpec",
+		Message:            "Data volumes match the persistence spec",
+		ObservedGeneration: redis.Generation,
+	})
+}
+
 // serviceForRedis returns a Redis Service object.
 func (r *RedisReconciler) serviceForRedis(redis *v1alpha1.Redis) *corev1.Service {
 	labels := labelsForRedis(redis.Name)
 	spec := redis.Spec.Service
 
+	// Writes must only reach the primary, so replicated instances select it by its role label.
+	selector := labelsForRedis(redis.Name)
+	if isReplicated(redis) {
+		selector[roleLabel] = rolePrimary
+	}
+
+	ports := []corev1.ServicePort{{Port: *spec.Port, TargetPort: intstr.FromInt32(*redis.Spec.Port), Name: "redis"}}
+	if hasExporter(redis) {
+		ports = append(ports, exporterServicePort())
+	}
+
 	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: redis.Namespace, Labels: labels},
 		Spec: corev1.ServiceSpec{
-			Selector: labels,
+			Selector: selector,
+			Ports:    ports,
+			Type:     corev1.ServiceType(spec.Type),
+		},
+	}
+
+	_ = ctrl.SetControllerReference(redis, svc, r.Scheme)
+
+	return svc
+}
+
+// readServiceForRedis returns the Service load-balancing reads across replicas, or nil if none is configured.
+func (r *RedisReconciler) readServiceForRedis(redis *v1alpha1.Redis) *corev1.Service {
+	spec := redis.Spec.Service.ReadService
+	if spec == nil || !isReplicated(redis) {
+		return nil
+	}
+
+	selector := labelsForRedis(redis.Name)
+	selector[roleLabel] = roleReplica
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: spec.Name, Namespace: redis.Namespace, Labels: readServiceLabels(redis.Name)},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
 			Ports:    []corev1.ServicePort{{Port: *spec.Port, TargetPort: intstr.FromInt32(*redis.Spec.Port), Name: "redis"}},
 			Type:     corev1.ServiceType(spec.Type),
 		},
@@ -247,8 +680,154 @@ func (r *RedisReconciler) serviceForRedis(redis *v1alpha1.Redis) *corev1.Service
 	return svc
 }
 
+// headlessServiceForRedis returns the headless Service that governs the Redis StatefulSet.
+func (r *RedisReconciler) headlessServiceForRedis(redis *v1alpha1.Redis) *corev1.Service {
+	labels := labelsForRedis(redis.Name)
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: headlessServiceName(redis), Namespace: redis.Namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector:  labels,
+			ClusterIP: corev1.ClusterIPNone,
+			// Pods must be resolvable before they are ready so that replicas can find each other while starting.
+			PublishNotReadyAddresses: true,
+			Ports:                    []corev1.ServicePort{{Port: *redis.Spec.Port, TargetPort: intstr.FromInt32(*redis.Spec.Port), Name: "redis"}},
+			Type:                     corev1.ServiceTypeClusterIP,
+		},
+	}
+
+	_ = ctrl.SetControllerReference(redis, svc, r.Scheme)
+
+	return svc
+}
+
 // deploymentForRedis returns a Redis Deployment object.
-func (r *RedisReconciler) deploymentForRedis(redis *v1alpha1.Redis) *appsv1.Deployment {
+func (r *RedisReconciler) deploymentForRedis(redis *v1alpha1.Redis, checksum string, restore *restoreSource) *appsv1.Deployment {
+	labels := labelsForRedis(redis.Name)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redis.Name,
+			Namespace: redis.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: redis.Spec.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: withRestore(withChecksum(podTemplateForRedis(redis), checksum), redis, restore),
+		},
+	}
+
+	_ = ctrl.SetControllerReference(redis, dep, r.Scheme)
+
+	return dep
+}
+
+// statefulSetForRedis returns a Redis StatefulSet object.
+func (r *RedisReconciler) statefulSetForRedis(redis *v1alpha1.Redis, checksum string, restore *restoreSource) *appsv1.StatefulSet {
+	labels := labelsForRedis(redis.Name)
+
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      redis.Name,
+			Namespace: redis.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             redisReplicas(redis),
+			ServiceName:          headlessServiceName(redis),
+			Selector:             &metav1.LabelSelector{MatchLabels: labels},
+			Template:             withRestore(withChecksum(podTemplateForRedis(redis), checksum), redis, restore),
+			VolumeClaimTemplates: volumeClaimTemplatesForRedis(redis),
+		},
+	}
+
+	_ = ctrl.SetControllerReference(redis, sts, r.Scheme)
+
+	return sts
+}
+
+// volumeClaimTemplatesForRedis returns the data volume claim templates for the Redis StatefulSet.
+func volumeClaimTemplatesForRedis(redis *v1alpha1.Redis) []corev1.PersistentVolumeClaim {
+	persistence := redis.Spec.Persistence
+	if persistence == nil {
+		return nil
+	}
+
+	accessModes := persistence.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+
+	return []corev1.PersistentVolumeClaim{{
+		ObjectMeta: metav1.ObjectMeta{Name: dataVolumeName, Labels: labelsForRedis(redis.Name)},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: persistence.StorageClassName,
+			AccessModes:      accessModes,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: persistence.Size},
+			},
+		},
+	}}
+}
+
+// redisServerArgs returns the redis-server arguments rendered from the spec.
+// It returns nil when nothing needs rendering, so plain instances keep the image entrypoint.
+// Restored instances always render, so the restore init containers share the data volume with Redis.
+func redisServerArgs(redis *v1alpha1.Redis) []string {
+	persistence := redis.Spec.Persistence
+	if persistence == nil && !isReplicated(redis) && !isClustered(redis) && !hasConfig(redis) && !hasTLS(redis) && redis.Spec.Restore == nil {
+		return nil
+	}
+
+	args := []string{"--port", strconv.Itoa(int(*redis.Spec.Port))}
+	if hasTLS(redis) {
+		// The plaintext port is closed, so clients, replicas and cluster nodes all connect over TLS.
+		args = append([]string{"--port", "0", "--tls-port", strconv.Itoa(int(*redis.Spec.Port))}, tlsServerArgs(redis)...)
+	}
+	args = append(args,
+		"--requirepass", "$(REDIS_PASSWORD)",
+		"--dir", redisDataDir,
+	)
+	if isReplicated(redis) {
+		// Replicas authenticate against the primary with the shared password and announce their
+		// stable DNS name rather than their pod IP, which changes whenever the pod is recreated.
+		args = append(args,
+			"--masterauth", "$(REDIS_PASSWORD)",
+			"--replica-announce-ip", fmt.Sprintf("$(POD_NAME).%s", headlessServiceHost(redis)),
+		)
+	}
+	if isClustered(redis) {
+		// The node table lives next to the data so that a restarted node rejoins with its identity.
+		// Clients are redirected to the stable DNS name of a node rather than its pod IP.
+		args = append(args,
+			"--masterauth", "$(REDIS_PASSWORD)",
+			"--cluster-enabled", "yes",
+			"--cluster-config-file", clusterConfigFile,
+			"--cluster-node-timeout", strconv.Itoa(clusterNodeTimeoutMillis),
+			"--cluster-announce-hostname", fmt.Sprintf("$(POD_NAME).%s", headlessServiceHost(redis)),
+			"--cluster-preferred-endpoint-type", "hostname",
+		)
+	}
+	if persistence == nil {
+		return args
+	}
+	if rdb := persistence.RDB; rdb != nil {
+		if rdb.Disabled {
+			args = append(args, "--save", "")
+		} else if len(rdb.SaveRules) > 0 {
+			args = append(args, "--save", strings.Join(rdb.SaveRules, " "))
+		}
+	}
+	if aof := persistence.AOF; aof != nil && aof.Enabled {
+		args = append(args, "--appendonly", "yes")
+		if aof.AppendFsync != "" {
+			args = append(args, "--appendfsync", string(aof.AppendFsync))
+		}
+	}
+	return args
+}
+
+// podTemplateForRedis returns the pod template shared by the Redis Deployment and StatefulSet.
+func podTemplateForRedis(redis *v1alpha1.Redis) corev1.PodTemplateSpec {
 	labels := labelsForRedis(redis.Name)
 
 	envVars := []corev1.EnvVar{{
@@ -256,80 +835,156 @@ func (r *RedisReconciler) deploymentForRedis(redis *v1alpha1.Redis) *appsv1.Depl
 		ValueFrom: &corev1.EnvVarSource{
 			SecretKeyRef: &corev1.SecretKeySelector{
 				LocalObjectReference: corev1.LocalObjectReference{Name: redis.Spec.PasswordSecretName},
-				Key:                  "password",
+				Key:                  v1alpha1.PasswordSecretKey(redis),
 			},
 		},
 	}}
+	if isReplicated(redis) || isClustered(redis) {
+		// The primary service only routes to ready pods, so the readiness probe's redis-cli must authenticate.
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "REDISCLI_AUTH",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: redis.Spec.PasswordSecretName},
+					Key:                  v1alpha1.PasswordSecretKey(redis),
+				},
+			},
+		}, corev1.EnvVar{
+			Name: "POD_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "metadata.name"},
+			},
+		})
+	}
 	if redis.Spec.Env != nil {
 		envVars = append(envVars, *redis.Spec.Env...)
 	}
 
-	// Define default Liveness Probe
-	livenessProbe := &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(*redis.Spec.Port)},
-		},
-		InitialDelaySeconds: 15,
-		TimeoutSeconds:      1,
-		PeriodSeconds:       20,
-		FailureThreshold:    3,
+	// Probes left empty, because the instance was stored without the defaulting webhook, get the same defaults.
+	livenessProbe := redis.Spec.LivenessProbe
+	if livenessProbe == nil {
+		livenessProbe = v1alpha1.DefaultLivenessProbe(redis)
 	}
-	// If user provided a LivenessProbe in the spec, use that instead of the default.
-	if redis.Spec.LivenessProbe != nil {
-		livenessProbe = redis.Spec.LivenessProbe
+	readinessProbe := redis.Spec.ReadinessProbe
+	if readinessProbe == nil {
+		readinessProbe = v1alpha1.DefaultReadinessProbe(redis)
 	}
 
-	// Define default Readiness Probe
-	readinessProbe := &corev1.Probe{
-		ProbeHandler: corev1.ProbeHandler{
-			Exec: &corev1.ExecAction{
-				Command: []string{"redis-cli", "ping"},
-			},
-		},
-		InitialDelaySeconds: 5,
-		TimeoutSeconds:      1,
-		PeriodSeconds:       10,
-		FailureThreshold:    3,
+	ports := []corev1.ContainerPort{{ContainerPort: *redis.Spec.Port, Name: "redis"}}
+	if isClustered(redis) {
+		ports = append(ports, corev1.ContainerPort{ContainerPort: clusterBusPort(redis), Name: "cluster-bus"})
 	}
-	// If user provided a ReadinessProbe in the spec, use that instead of the default.
-	if redis.Spec.ReadinessProbe != nil {
-		readinessProbe = redis.Spec.ReadinessProbe
+
+	container := corev1.Container{
+		Image:          redis.Spec.Image,
+		Name:           "redis",
+		Ports:          ports,
+		Env:            envVars,
+		Resources:      redis.Spec.Resources,
+		ReadinessProbe: readinessProbe,
+		LivenessProbe:  livenessProbe,
+	}
+	var volumes []corev1.Volume
+	// Rendered arguments bypass the image entrypoint and start redis-server directly. The data
+	// directory is then always mounted, backed by an emptyDir when persistence is disabled.
+	if args := redisServerArgs(redis); len(args) > 0 {
+		container.Command = []string{"redis-server"}
+		container.Args = args
+		container.VolumeMounts = []corev1.VolumeMount{{Name: dataVolumeName, MountPath: redisDataDir}}
+		if redis.Spec.Persistence == nil {
+			volumes = append(volumes, corev1.Volume{
+				Name:         dataVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			})
+		}
 	}
 
-	dep := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      redis.Name,
-			Namespace: redis.Namespace,
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: redis.Spec.Replicas,
-			Selector: &metav1.LabelSelector{MatchLabels: labels},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{
-						Image:          redis.Spec.Image,
-						Name:           "redis",
-						Ports:          []corev1.ContainerPort{{ContainerPort: *redis.Spec.Port, Name: "redis"}},
-						Env:            envVars,
-						Resources:      redis.Spec.Resources,
-						ReadinessProbe: readinessProbe,
-						LivenessProbe:  livenessProbe,
-					}},
-				},
-			},
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{container},
+			Volumes:    volumes,
 		},
 	}
-
-	_ = ctrl.SetControllerReference(redis, dep, r.Scheme)
-
-	return dep
+	if redis.Spec.Persistence != nil {
+		template.Spec.SecurityContext = &corev1.PodSecurityContext{FSGroup: ptr.To(redisFSGroup)}
+	}
+	if hasConfig(redis) {
+		addConfigToPodTemplate(redis, &template)
+	}
+	if rotatesPassword(redis) {
+		addAuthVolume(redis, &template.Spec, &template.Spec.Containers[0])
+	}
+	if hasTLS(redis) {
+		addTLSVolume(redis, &template.Spec, &template.Spec.Containers[0])
+	}
+	if hasExporter(redis) {
+		addExporter(redis, &template.Spec)
+	}
+	return template
 }
 
 func labelsForRedis(name string) map[string]string {
 	return map[string]string{"app": "redis", "redis_cr": name}
 }
 
+// readServiceLabels returns the labels identifying the read service of a Redis instance.
+func readServiceLabels(name string) map[string]string {
+	labels := labelsForRedis(name)
+	labels["redis.yazio.com/service"] = "read"
+	return labels
+}
+
+// serviceEndpoint returns the in-cluster host:port of a service.
+func serviceEndpoint(name string, namespace string, port int32) string {
+	return fmt.Sprintf("%s.%s.svc:%d", name, namespace, port)
+}
+
+// headlessServiceName returns the name of the headless service governing the Redis StatefulSet.
+func headlessServiceName(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-headless", redis.Name)
+}
+
+// headlessServiceHost returns the DNS domain under which the Redis StatefulSet pods are resolvable.
+func headlessServiceHost(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s.%s.svc", headlessServiceName(redis), redis.Namespace)
+}
+
+// podHost returns the stable DNS name of a Redis StatefulSet pod.
+func podHost(redis *v1alpha1.Redis, podName string) string {
+	return fmt.Sprintf("%s.%s", podName, headlessServiceHost(redis))
+}
+
+// isStatefulSet reports whether the Redis pods are run by a StatefulSet.
+// Persistent data volumes, replication and clustering need stable pod identities, so they imply a StatefulSet.
+func isStatefulSet(redis *v1alpha1.Redis) bool {
+	return redis.Spec.WorkloadKind == v1alpha1.WorkloadKindStatefulSet || redis.Spec.Persistence != nil ||
+		isReplicated(redis) || isClustered(redis)
+}
+
+// isReplicated reports whether the Redis pods run as a primary with replicas.
+func isReplicated(redis *v1alpha1.Redis) bool {
+	return redis.Spec.Mode == v1alpha1.ModeReplication
+}
+
+// isClustered reports whether the Redis pods form a sharded Redis Cluster.
+func isClustered(redis *v1alpha1.Redis) bool {
+	return redis.Spec.Mode == v1alpha1.ModeCluster && redis.Spec.Cluster != nil
+}
+
+// redisReplicas returns the number of Redis pods. In cluster mode it follows from the shard layout,
+// and shards that are being removed keep their pods until their slots have been migrated away.
+func redisReplicas(redis *v1alpha1.Redis) *int32 {
+	if isClustered(redis) {
+		shards := redis.Spec.Cluster.Shards
+		if status := redis.Status.Cluster; status != nil && status.Shards > shards {
+			shards = status.Shards
+		}
+		return ptr.To(shards * (1 + redis.Spec.Cluster.ReplicasPerShard))
+	}
+	return redis.Spec.Replicas
+}
+
 func generateRandomPassword(length int) (string, error) {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	b := make([]byte, length)
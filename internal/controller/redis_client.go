@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// redisDialTimeout bounds how long the operator waits to connect to a Redis pod.
+	redisDialTimeout = 5 * time.Second
+	// redisCommandTimeout bounds how long the operator waits for a single Redis command.
+	redisCommandTimeout = 10 * time.Second
+)
+
+// newRedisClient returns a client for a single Redis server, connecting over TLS if a TLS configuration is
+// given. The operator talks to one pod at a time, so the client keeps a single connection and does not retry
+// on its own; the next reconcile does.
+func newRedisClient(host string, port int32, password string, tlsConfig *tls.Config) *goredis.Client {
+	return goredis.NewClient(&goredis.Options{
+		Addr:         net.JoinHostPort(host, strconv.Itoa(int(port))),
+		Password:     password,
+		TLSConfig:    tlsConfig,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisCommandTimeout,
+		WriteTimeout: redisCommandTimeout,
+		PoolSize:     1,
+		MaxRetries:   -1,
+	})
+}
+
+// redisClientForPod returns a client connected to the Redis server running in the given pod.
+func redisClientForPod(redis *v1alpha1.Redis, pod *corev1.Pod, password string, tlsConfig *tls.Config) *goredis.Client {
+	return newRedisClient(pod.Status.PodIP, *redis.Spec.Port, password, tlsConfig)
+}
+
+// redisPassword reads the Redis password from the password secret.
+func (r *RedisReconciler) redisPassword(ctx context.Context, redis *v1alpha1.Redis) (string, error) {
+	return readRedisPassword(ctx, r.Client, redis)
+}
+
+// readRedisPassword reads the Redis password from the password secret with the given client.
+func readRedisPassword(ctx context.Context, c client.Reader, redis *v1alpha1.Redis) (string, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: redis.Spec.PasswordSecretName, Namespace: redis.Namespace}, secret); err != nil {
+		return "", err
+	}
+	key := v1alpha1.PasswordSecretKey(redis)
+	password, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no %s key", secret.Name, key)
+	}
+	return string(password), nil
+}
+
+// redisPods lists the pods of the Redis instance ordered by name, which is ordinal order for StatefulSets.
+func (r *RedisReconciler) redisPods(ctx context.Context, redis *v1alpha1.Redis) ([]corev1.Pod, error) {
+	return listRedisPods(ctx, r.Client, redis)
+}
+
+// listRedisPods lists the pods of the Redis instance with the given client, ordered by name.
+func listRedisPods(ctx context.Context, c client.Reader, redis *v1alpha1.Redis) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, client.InNamespace(redis.Namespace), client.MatchingLabels(labelsForRedis(redis.Name))); err != nil {
+		return nil, err
+	}
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool {
+		return podOrdinal(pods[i].Name) < podOrdinal(pods[j].Name)
+	})
+	return pods, nil
+}
+
+// podOrdinal returns the StatefulSet ordinal of a pod, or -1 if the name carries none.
+func podOrdinal(podName string) int {
+	i := strings.LastIndex(podName, "-")
+	if i < 0 {
+		return -1
+	}
+	ordinal, err := strconv.Atoi(podName[i+1:])
+	if err != nil {
+		return -1
+	}
+	return ordinal
+}
+
+// podRunning reports whether the Redis container of a pod is running and reachable.
+func podRunning(pod *corev1.Pod) bool {
+	return containerRunning(pod, "redis")
+}
+
+// containerRunning reports whether the named container of a pod is running and reachable.
+func containerRunning(pod *corev1.Pod, containerName string) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.PodIP == "" {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.State.Running != nil
+		}
+	}
+	return false
+}
+
+// redisInfo runs INFO for a single section and parses the reply into a key/value map.
+func redisInfo(ctx context.Context, rdb *goredis.Client, section string) (map[string]string, error) {
+	reply, err := rdb.Info(ctx, section).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(reply), nil
+}
+
+// parseInfo parses the "key:value" lines of an INFO reply, skipping section headers and blank lines.
+func parseInfo(reply string) map[string]string {
+	info := map[string]string{}
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			info[key] = value
+		}
+	}
+	return info
+}
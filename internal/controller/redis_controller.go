@@ -20,6 +20,7 @@ import (
 	"context"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -30,6 +31,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
@@ -40,6 +42,8 @@ type RedisReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	tlsConfigs clientTLSConfigs
 }
 
 const (
@@ -48,8 +52,14 @@ const (
 )
 
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=redis.yazio.com,resources=redis,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=redis.yazio.com,resources=redis/status,verbs=get;update;patch
@@ -78,7 +88,7 @@ func (r *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	// so we need to set initial status conditions to let users know operator is working on this specific resource
 	if len(redis.Status.Conditions) == 0 {
 		meta.SetStatusCondition(&redis.Status.Conditions, metav1.Condition{
-			Type:    "Available",
+			Type:    conditionAvailable,
 			Status:  metav1.ConditionUnknown,
 			Reason:  "Reconciling",
 			Message: "Reconciling redis",
@@ -111,7 +121,14 @@ func (r *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		if controllerutil.ContainsFinalizer(redis, FinalizerName) {
 			// Since every resource created with controller reference once redis cr is deleted,
 			// other resource will be deleted by garbage collector automatically
-			// so controller only needs to remove finalizer and throw event
+			// so controller only needs to apply the deletion policy, remove finalizer and throw event
+			finalized, err := r.finalizeRedis(ctx, redis)
+			if err != nil {
+				return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+			}
+			if !finalized {
+				return ctrl.Result{RequeueAfter: RequeueDelay}, nil
+			}
 			log.Info("Removing finalizer from Redis", "name", redis.Name)
 
 			if err := r.Get(ctx, req.NamespacedName, redis); err != nil {
@@ -133,17 +150,103 @@ func (r *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if _, err := r.reconcileSecret(ctx, redis); err != nil {
 		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
 	}
+	renewalDelay, err := r.reconcileTLS(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
 	if _, err := r.reconcileService(ctx, redis); err != nil {
 		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
 	}
-	deployment, err := r.reconcileDeployment(ctx, redis)
+	if err := r.reconcileConfig(ctx, redis); err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	restore, err := r.resolveRestore(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	if redis.Spec.Restore != nil && restore == nil {
+		// The pods are neither created nor updated until the snapshot to restore is available.
+		return ctrl.Result{RequeueAfter: RequeueDelay}, nil
+	}
+	if err := r.deleteStaleWorkload(ctx, redis); err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+
+	var workload client.Object
+	if isStatefulSet(redis) {
+		if _, err := r.reconcileHeadlessService(ctx, redis); err != nil {
+			return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+		}
+		statefulSet, err := r.reconcileStatefulSet(ctx, redis, restore)
+		if err != nil {
+			return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+		}
+		if err := r.reconcileVolumeExpansion(ctx, redis, statefulSet); err != nil {
+			return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+		}
+		workload = statefulSet
+	} else {
+		deployment, err := r.reconcileDeployment(ctx, redis, restore)
+		if err != nil {
+			return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+		}
+		workload = deployment
+	}
+	if err := r.reconcileAutoscaler(ctx, redis); err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	restored, err := r.reportRestore(ctx, redis, restore)
 	if err != nil {
 		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
 	}
+	if restore != nil && restored {
+		if err := r.markRestored(ctx, redis); err != nil {
+			return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+		}
+	}
 
-	if err = r.updateStatus(ctx, redis, deployment); err != nil {
+	failoverInProgress, err := r.reconcileFailover(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	replicationReady, err := r.reconcileReplication(ctx, redis)
+	if err != nil {
 		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
 	}
+	if err := r.reconcileSentinel(ctx, redis); err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	clusterReady, err := r.reconcileCluster(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	configApplied, err := r.reconcileLiveConfig(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	rotationDelay, err := r.reconcilePasswordRotation(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+	backupDelay, err := r.reconcileScheduledBackups(ctx, redis)
+	if err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+
+	if err = r.updateStatus(ctx, redis, workload); err != nil {
+		return requeueInstanceWithError(ctx, redis.Name, redis.Namespace, err)
+	}
+
+	// Replication, clustering, runtime config and restores are applied to live pods, so keep polling until
+	// every pod is configured and, with Sentinel, for as long as Sentinel may fail over.
+	if !replicationReady || !clusterReady || failoverInProgress || !configApplied || !restored {
+		return ctrl.Result{RequeueAfter: RequeueDelay}, nil
+	}
+	// Password rotations, certificate renewals and scheduled backups need to be picked up again once their
+	// next step is due.
+	if delay := earliestDelay(rotationDelay, renewalDelay, backupDelay); delay > 0 {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
 
 	return reconciled()
 }
@@ -153,8 +256,14 @@ func (r *RedisReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&redisv1alpha1.Redis{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.redisForConsumedObject)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.redisForConsumedObject)).
+		Watches(&redisv1alpha1.RedisBackup{}, handler.EnqueueRequestsFromMapFunc(r.redisForBackup)).
 		Named("redis").
 		Complete(r)
 }
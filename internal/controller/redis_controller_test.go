@@ -18,16 +18,25 @@ package controller
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/robfig/cron/v3"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -238,6 +247,9 @@ var _ = Describe("Redis Controller", func() {
 				return nil
 			}, timeout, interval).Should(Succeed(), "Status should be updated with the secret name")
 			Expect(redis.Status.PasswordSecretName).To(Equal(secretLookupKey.Name))
+			Expect(redis.Status.Phase).To(Equal(redisv1alpha1.PhasePending))
+			Expect(redis.Status.ObservedGeneration).To(Equal(redis.Generation))
+			Expect(redis.Status.PrimaryEndpoint).To(Equal("redis-service.default.svc:6379"))
 
 			By("Checking replica count update in the Deployment")
 			newReplicas := int32(3)
@@ -290,4 +302,1725 @@ var _ = Describe("Redis Controller", func() {
 			))
 		})
 	})
+
+	Context("When reconciling a StatefulSet-backed resource", func() {
+		const (
+			resourceName      = "test-statefulset"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with a StatefulSet workload")
+			resource := newTestRedis(resourceName, resourceNamespace)
+			replicas := int32(2)
+			resource.Spec.Replicas = &replicas
+			resource.Spec.WorkloadKind = redisv1alpha1.WorkloadKindStatefulSet
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should manage a StatefulSet and its headless Service", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking if the headless Service is created")
+			headless := &corev1.Service{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-headless", Namespace: resourceNamespace}, headless)
+			}, timeout, interval).Should(Succeed())
+			Expect(headless.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+			Expect(headless.Spec.PublishNotReadyAddresses).To(BeTrue())
+
+			By("Checking if the StatefulSet is created instead of a Deployment")
+			statefulSet := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, statefulSet)
+			}, timeout, interval).Should(Succeed())
+			Expect(statefulSet.Spec.ServiceName).To(Equal(headless.Name))
+			Expect(*statefulSet.Spec.Replicas).To(Equal(int32(2)))
+			Expect(statefulSet.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(statefulSet.Spec.Template.Spec.Containers[0].LivenessProbe).NotTo(BeNil())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, redisLookupKey, &appsv1.Deployment{}))).To(BeTrue())
+		})
+	})
+
+	Context("When reconciling a resource with persistence", func() {
+		const (
+			resourceName      = "test-persistence"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with persistence enabled")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Persistence = &redisv1alpha1.Persistence{
+				StorageClassName: ptr.To("standard"),
+				Size:             resource.MustParse("2Gi"),
+				RDB:              &redisv1alpha1.RDB{SaveRules: []string{"900 1", "300 10"}},
+				AOF:              &redisv1alpha1.AOF{Enabled: true, AppendFsync: "everysec"},
+			}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should render volume claim templates and report storage drift", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the StatefulSet claim templates and data mount")
+			statefulSet := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, statefulSet)
+			}, timeout, interval).Should(Succeed())
+			Expect(statefulSet.Spec.VolumeClaimTemplates).To(HaveLen(1))
+			claim := statefulSet.Spec.VolumeClaimTemplates[0]
+			Expect(claim.Name).To(Equal("data"))
+			Expect(claim.Spec.StorageClassName).To(HaveValue(Equal("standard")))
+			Expect(claim.Spec.AccessModes).To(ConsistOf(corev1.ReadWriteOnce))
+			Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("2Gi"))
+
+			container := statefulSet.Spec.Template.Spec.Containers[0]
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "data", MountPath: "/data"}))
+			Expect(container.Command).To(Equal([]string{"redis-server"}))
+			Expect(container.Args).To(ContainElements("--dir", "/data", "--save", "900 1 300 10", "--appendonly", "yes", "--appendfsync", "everysec"))
+			Expect(statefulSet.Spec.Template.Spec.SecurityContext).NotTo(BeNil())
+			Expect(statefulSet.Spec.Template.Spec.SecurityContext.FSGroup).To(HaveValue(Equal(int64(1001))))
+
+			By("Changing the storage size and checking the status condition")
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			redis.Spec.Persistence.Size = resource.MustParse("4Gi")
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, redisLookupKey, redis); err != nil {
+					return ""
+				}
+				condition := meta.FindStatusCondition(redis.Status.Conditions, "StorageSynced")
+				if condition == nil {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal("StorageSizeChanged"))
+		})
+	})
+
+	Context("When shrinking persistent volumes", func() {
+		const (
+			resourceName      = "test-shrink"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating an existing data claim larger than the requested size")
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "data-" + resourceName + "-0",
+					Namespace: resourceNamespace,
+					Labels:    labelsForRedis(resourceName),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("4Gi")},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Persistence = &redisv1alpha1.Persistence{Size: resource.MustParse("1Gi")}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should reject the shrink on the VolumeResizing condition", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			redis := &redisv1alpha1.Redis{}
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, redisLookupKey, redis); err != nil {
+					return ""
+				}
+				condition := meta.FindStatusCondition(redis.Status.Conditions, "VolumeResizing")
+				if condition == nil {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal("VolumeShrinkRejected"))
+
+			claim := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "data-" + resourceName + "-0", Namespace: resourceNamespace}, claim)).To(Succeed())
+			Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("4Gi"))
+		})
+	})
+
+	Context("When expanding persistent volumes", func() {
+		const resourceNamespace = "default"
+
+		ctx := context.Background()
+
+		// createWithClaim creates a data claim of the given storage class and a Redis requesting a larger size.
+		createWithClaim := func(name string, storageClassName *string) *corev1.PersistentVolumeClaim {
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "data-" + name + "-0",
+					Namespace: resourceNamespace,
+					Labels:    labelsForRedis(name),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: storageClassName,
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+
+			redis := newTestRedis(name, resourceNamespace)
+			redis.Spec.Persistence = &redisv1alpha1.Persistence{StorageClassName: storageClassName, Size: resource.MustParse("2Gi")}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+			return claim
+		}
+
+		cleanup := func(name string, claim *corev1.PersistentVolumeClaim) {
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: resourceNamespace}, redis)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(redis)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, claim))).To(Succeed())
+		}
+
+		It("should expand claims of the default storage class", func() {
+			storageClass := &storagev1.StorageClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-default",
+					Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+				},
+				Provisioner:          "example.com/test",
+				AllowVolumeExpansion: ptr.To(true),
+			}
+			Expect(k8sClient.Create(ctx, storageClass)).To(Succeed())
+			DeferCleanup(func() { Expect(k8sClient.Delete(ctx, storageClass)).To(Succeed()) })
+
+			claim := createWithClaim("test-expand-default", nil)
+			DeferCleanup(cleanup, "test-expand-default", claim)
+
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-expand-default", Namespace: resourceNamespace}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)).To(Succeed())
+			Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("2Gi"))
+		})
+
+		It("should report a missing storage class on the VolumeResizing condition", func() {
+			claim := createWithClaim("test-expand-missing", ptr.To("missing"))
+			DeferCleanup(cleanup, "test-expand-missing", claim)
+
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			redisLookupKey := types.NamespacedName{Name: "test-expand-missing", Namespace: resourceNamespace}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			condition := meta.FindStatusCondition(redis.Status.Conditions, "VolumeResizing")
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("StorageClassNotFound"))
+		})
+	})
+
+	Context("When reconciling a replicated resource", func() {
+		const (
+			resourceName      = "test-replication"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis in replication mode")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			replicas := int32(3)
+			redis.Spec.Replicas = &replicas
+			redis.Spec.Mode = redisv1alpha1.ModeReplication
+			redis.Spec.Service.ReadService = &redisv1alpha1.ReadService{Name: resourceName + "-read"}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should run a StatefulSet and route the Services by role", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(RequeueDelay), "replication should be retried until pods are running")
+
+			By("Checking the Service selects the primary role")
+			service := &corev1.Service{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-service", Namespace: resourceNamespace}, service)
+			}, timeout, interval).Should(Succeed())
+			Expect(service.Spec.Selector).To(HaveKeyWithValue("redis.yazio.com/role", "primary"))
+
+			By("Checking the read Service selects the replica role")
+			readService := &corev1.Service{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-read", Namespace: resourceNamespace}, readService)
+			}, timeout, interval).Should(Succeed())
+			Expect(readService.Spec.Selector).To(HaveKeyWithValue("redis.yazio.com/role", "replica"))
+			Expect(readService.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(readService.Spec.Ports[0].Port).To(Equal(int32(6379)))
+
+			By("Checking both endpoints are published on the status")
+			redis := &redisv1alpha1.Redis{}
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, redisLookupKey, redis); err != nil {
+					return ""
+				}
+				return redis.Status.ReadEndpoint
+			}, timeout, interval).Should(Equal("test-replication-read.default.svc:6379"))
+			Expect(redis.Status.PrimaryEndpoint).To(Equal("test-replication-service.default.svc:6379"))
+
+			By("Checking the StatefulSet renders the replication arguments")
+			statefulSet := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, statefulSet)
+			}, timeout, interval).Should(Succeed())
+			Expect(statefulSet.Spec.Selector.MatchLabels).NotTo(HaveKey("redis.yazio.com/role"))
+			container := statefulSet.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("--masterauth", "$(REDIS_PASSWORD)", "--replica-announce-ip",
+				"$(POD_NAME).test-replication-headless.default.svc"))
+			Expect(container.Env).To(ContainElement(HaveField("Name", "POD_NAME")))
+			Expect(statefulSet.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", "data")))
+		})
+	})
+
+	Context("When reconciling a resource with Sentinel", func() {
+		const (
+			resourceName      = "test-sentinel"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+		sentinelLookupKey := types.NamespacedName{Name: resourceName + "-sentinel", Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with Sentinel")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			replicas := int32(3)
+			redis.Spec.Replicas = &replicas
+			redis.Spec.Mode = redisv1alpha1.ModeReplication
+			redis.Spec.Sentinel = &redisv1alpha1.Sentinel{Quorum: 2, DownAfterMilliseconds: 5000, FailoverTimeout: 60000}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should deploy Sentinel monitoring the primary", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the Sentinel config points at the first pod")
+			configMap := &corev1.ConfigMap{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, sentinelLookupKey, configMap)
+			}, timeout, interval).Should(Succeed())
+			config := configMap.Data["sentinel.conf"]
+			Expect(config).To(ContainSubstring("sentinel monitor test-sentinel test-sentinel-0.test-sentinel-headless.default.svc 6379 2"))
+			Expect(config).To(ContainSubstring("sentinel down-after-milliseconds test-sentinel 5000"))
+			Expect(config).To(ContainSubstring("sentinel failover-timeout test-sentinel 60000"))
+			Expect(config).NotTo(ContainSubstring("auth-pass"), "the password must not be stored in the config map")
+
+			By("Checking the Sentinel Deployment and Service exist")
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, sentinelLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort).To(Equal(int32(26379)))
+
+			service := &corev1.Service{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, sentinelLookupKey, service)
+			}, timeout, interval).Should(Succeed())
+			Expect(service.Spec.Selector).To(Equal(deployment.Spec.Template.Labels))
+
+			By("Removing Sentinel from the spec")
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			redis.Spec.Sentinel = nil
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, sentinelLookupKey, &appsv1.Deployment{}))
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("should map Sentinel addresses to pods", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			pods := []corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-sentinel-0"}, Status: corev1.PodStatus{PodIP: "10.0.0.1"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-sentinel-1"}, Status: corev1.PodStatus{PodIP: "10.0.0.2"}},
+			}
+
+			Expect(podForHost(redis, pods, "test-sentinel-1.test-sentinel-headless.default.svc").Name).To(Equal("test-sentinel-1"))
+			Expect(podForHost(redis, pods, "test-sentinel-1.test-sentinel-headless.default.svc.cluster.local").Name).To(Equal("test-sentinel-1"))
+			Expect(podForHost(redis, pods, "10.0.0.1").Name).To(Equal("test-sentinel-0"))
+			Expect(podForHost(redis, pods, "10.0.0.9")).To(BeNil())
+		})
+	})
+
+	Context("When reconciling a resource in cluster mode", func() {
+		const (
+			resourceName      = "test-cluster"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis in cluster mode")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Mode = redisv1alpha1.ModeCluster
+			redis.Spec.Cluster = &redisv1alpha1.Cluster{Shards: 3, ReplicasPerShard: 1}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should run a cluster-enabled StatefulSet sized by the shard layout", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(RequeueDelay), "the cluster should be bootstrapped once pods are running")
+
+			statefulSet := &appsv1.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, statefulSet)
+			}, timeout, interval).Should(Succeed())
+			Expect(*statefulSet.Spec.Replicas).To(Equal(int32(6)))
+
+			container := statefulSet.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("--cluster-enabled", "yes", "--cluster-config-file", "/data/nodes.conf",
+				"--cluster-announce-hostname", "$(POD_NAME).test-cluster-headless.default.svc"))
+			Expect(container.Ports).To(ContainElement(HaveField("ContainerPort", int32(16379))))
+		})
+
+		It("should parse the cluster node table", func() {
+			nodes := parseClusterNodes("" +
+				"07c37dfeb235213a872192d90877d0cd55635b91 10.0.0.1:6379@16379,test-cluster-0.test-cluster-headless.default.svc myself,master - 0 0 1 connected 0-5460\n" +
+				"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 10.0.0.2:6379@16379 slave 07c37dfeb235213a872192d90877d0cd55635b91 0 1426238317239 1 connected\n" +
+				"292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 10.0.0.3:6379@16379 master,fail - 0 1426238316232 2 disconnected 5461-10922 [5461->-e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca]\n")
+
+			Expect(nodes).To(HaveLen(3))
+			Expect(nodes[0].Myself).To(BeTrue())
+			Expect(nodes[0].Primary).To(BeTrue())
+			Expect(nodes[0].IP).To(Equal("10.0.0.1"))
+			Expect(nodes[0].Hostname).To(Equal("test-cluster-0.test-cluster-headless.default.svc"))
+			Expect(nodes[0].Slots).To(Equal([]string{"0-5460"}))
+			Expect(nodes[1].Primary).To(BeFalse())
+			Expect(nodes[1].PrimaryID).To(Equal("07c37dfeb235213a872192d90877d0cd55635b91"))
+			Expect(nodes[2].Failed).To(BeTrue())
+			Expect(nodes[2].Connected).To(BeFalse())
+			Expect(nodes[2].Slots).To(Equal([]string{"5461-10922"}))
+		})
+
+		It("should split the hash slots evenly across shards", func() {
+			Expect(slotRanges(3)).To(Equal([][2]int{{0, 5461}, {5462, 10922}, {10923, 16383}}))
+			Expect(countSlots([]string{"0-5461", "5462", "10923-16383"})).To(Equal(int32(10924)))
+		})
+
+		It("should plan bounded slot moves when shards are added or removed", func() {
+			owned := [][]int{expandSlots([]string{"0-5461"}), expandSlots([]string{"5462-10922"}), expandSlots([]string{"10923-16383"}), nil}
+
+			By("adding a fourth shard")
+			targets := slotTargets(4, 4)
+			Expect(targets).To(Equal([]int{4096, 4096, 4096, 4096}))
+			moves, remaining := planSlotMoves(owned, targets, 64)
+			Expect(remaining).To(Equal(4096))
+			Expect(moves).To(HaveLen(64))
+			for _, move := range moves {
+				Expect(move.to).To(Equal(3))
+			}
+			Expect(moves[0]).To(Equal(slotMove{slot: 4096, from: 0, to: 3}))
+
+			By("draining the third shard")
+			moves, remaining = planSlotMoves(owned[:3], slotTargets(2, 3), 10000)
+			Expect(remaining).To(Equal(5461))
+			for _, move := range moves {
+				Expect(move.from).To(Equal(2))
+			}
+		})
+
+		It("should parse slots that are being migrated", func() {
+			nodes := parseClusterNodes("07c37dfeb235213a872192d90877d0cd55635b91 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-5460 [5461->-292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f]\n")
+			Expect(nodes[0].Slots).To(Equal([]string{"0-5460"}))
+			Expect(nodes[0].Migrating).To(HaveKeyWithValue(5461, "292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f"))
+
+			nodes = parseClusterNodes("292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 10.0.0.2:6379@16379 myself,master - 0 0 2 connected 5462-10922 [5461-<-07c37dfeb235213a872192d90877d0cd55635b91]\n")
+			Expect(nodes[0].Slots).To(Equal([]string{"5462-10922"}))
+			Expect(nodes[0].Migrating).To(BeEmpty())
+			Expect(nodes[0].Importing).To(HaveKeyWithValue(5461, "07c37dfeb235213a872192d90877d0cd55635b91"))
+		})
+	})
+
+	Context("When a failover is requested for a standalone resource", func() {
+		const (
+			resourceName      = "test-failover"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with a failover request")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Annotations = map[string]string{"redis.yazio.com/failover-to": "test-failover-1"}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should reject the failover and clear the annotation", func() {
+			recorder := record.NewFakeRecorder(20)
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: recorder,
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(redis.Annotations).NotTo(HaveKey("redis.yazio.com/failover-to"))
+			Eventually(recorder.Events).Should(Receive(ContainSubstring("FailoverRejected")))
+		})
+
+		It("should restore the configured replica-priority after a Sentinel failover", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			Expect(replicaPriority(redis)).To(Equal("100"))
+			redis.Spec.Config = map[string]string{"Replica-Priority": "50"}
+			Expect(replicaPriority(redis)).To(Equal("50"))
+		})
+
+		It("should give up a Sentinel failover once Sentinel no longer retries it", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Sentinel = &redisv1alpha1.Sentinel{FailoverTimeout: 60000}
+			Expect(sentinelFailoverTimeout(redis)).To(Equal(2 * time.Minute))
+			redis.Spec.Sentinel.FailoverTimeout = 0
+			Expect(sentinelFailoverTimeout(redis)).To(Equal(time.Minute))
+		})
+	})
+
+	Context("When reconciling a resource with spec.config", func() {
+		const (
+			resourceName      = "test-config"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with config directives")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Config = map[string]string{"maxmemory": "100mb", "maxmemory-policy": "allkeys-lru"}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should render redis.conf into a mounted config map", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			configMap := &corev1.ConfigMap{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-config", Namespace: resourceNamespace}, configMap)
+			}, timeout, interval).Should(Succeed())
+			Expect(configMap.Data["redis.conf"]).To(Equal("maxmemory 100mb\nmaxmemory-policy allkeys-lru\n"))
+
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Command).To(Equal([]string{"redis-server"}))
+			Expect(container.Args[0]).To(Equal("/etc/redis/redis.conf"))
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "config", MountPath: "/etc/redis"}))
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(ContainElement(HaveField("Name", "config")))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(SatisfyAll(
+				HaveField("Name", "config"),
+				HaveField("EmptyDir.Medium", corev1.StorageMediumMemory),
+			)))
+			Expect(deployment.Spec.Template.Annotations).To(HaveKey("redis.yazio.com/config-hash"))
+		})
+
+		It("should only roll the pods for restart-required directives", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Config = map[string]string{"maxmemory-policy": "allkeys-lru", "databases": "16"}
+			before := podTemplateForRedis(redis)
+
+			By("changing a hot-reloadable directive")
+			redis.Spec.Config["maxmemory-policy"] = "volatile-lru"
+			Expect(templatesMatch(&before, ptr.To(podTemplateForRedis(redis)))).To(BeTrue())
+
+			By("changing a restart-required directive")
+			redis.Spec.Config["databases"] = "32"
+			Expect(templatesMatch(&before, ptr.To(podTemplateForRedis(redis)))).To(BeFalse())
+
+			hot, restartRequired := splitConfig(redis.Spec.Config)
+			Expect(hot).To(Equal(map[string]string{"maxmemory-policy": "volatile-lru"}))
+			Expect(restartRequired).To(Equal(map[string]string{"databases": "32"}))
+
+			By("treating directives Redis refuses to CONFIG SET as restart-required")
+			_, restartRequired = splitConfig(map[string]string{"appendfilename": "aof", "cluster-port": "16380", "aclfile": "/etc/users.acl"})
+			Expect(restartRequired).To(HaveLen(3))
+		})
+
+		It("should notice hot-reloadable directives removed from spec.config", func() {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				"redis.yazio.com/applied-config-keys": configKeys(map[string]string{"Maxmemory": "100mb", "timeout": "0"}),
+			}}}
+			Expect(removedConfigKeys(pod, map[string]string{"maxmemory": "200mb", "timeout": "0"})).To(BeEmpty())
+			Expect(removedConfigKeys(pod, map[string]string{"maxmemory": "200mb"})).To(Equal([]string{"timeout"}))
+			Expect(removedConfigKeys(pod, map[string]string{})).To(Equal([]string{"maxmemory", "timeout"}))
+			Expect(removedConfigKeys(&corev1.Pod{}, map[string]string{})).To(BeEmpty())
+		})
+
+		It("should reject directives managed by the operator", func() {
+			redis := newTestRedis(resourceName+"-invalid", resourceNamespace)
+			redis.Spec.Config = map[string]string{"requirepass": "secret"}
+			Expect(k8sClient.Create(ctx, redis)).NotTo(Succeed())
+			Expect(redisv1alpha1.ManagedConfigKeysIn(map[string]string{"Port": "6380", "timeout": "0"})).To(Equal([]string{"Port"}))
+		})
+
+		It("should reject line breaks that would inject directives", func() {
+			redis := newTestRedis(resourceName+"-invalid", resourceNamespace)
+			redis.Spec.Config = map[string]string{"maxmemory": "1gb\nrequirepass x"}
+			Expect(k8sClient.Create(ctx, redis)).NotTo(Succeed())
+			redis.Spec.Config = map[string]string{"maxmemory requirepass": "x"}
+			Expect(k8sClient.Create(ctx, redis)).NotTo(Succeed())
+			Expect(redisv1alpha1.MalformedConfigKeysIn(map[string]string{"maxmemory": "1gb\r\nrequirepass x", "timeout": "0", "a b": "c"})).To(Equal([]string{`"a b"`, `"maxmemory"`}))
+		})
+	})
+
+	Context("When a consumed Secret changes", func() {
+		const (
+			resourceName      = "test-checksum"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should roll the pods through the checksum annotation", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			checksum := deployment.Spec.Template.Annotations["redis.yazio.com/checksum"]
+			Expect(checksum).NotTo(BeEmpty())
+
+			By("Editing the password Secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-password", Namespace: resourceNamespace}, secret)).To(Succeed())
+			Expect(controllerReconciler.redisForConsumedObject(ctx, secret)).To(ContainElement(reconcile.Request{NamespacedName: redisLookupKey}))
+			secret.Data["password"] = []byte("changed")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, redisLookupKey, deployment); err != nil {
+					return checksum
+				}
+				return deployment.Spec.Template.Annotations["redis.yazio.com/checksum"]
+			}, timeout, interval).ShouldNot(Equal(checksum))
+		})
+	})
+
+	Context("When rotating the password", func() {
+		const (
+			resourceName      = "test-rotation"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+		secretLookupKey := types.NamespacedName{Name: resourceName + "-password", Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.PasswordRotation = &redisv1alpha1.PasswordRotation{}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should rotate the password on request and drop the old one after the grace period", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			checksum := deployment.Spec.Template.Annotations["redis.yazio.com/checksum"]
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", authVolumeName)))
+			Expect(deployment.Spec.Template.Spec.Containers[0].ReadinessProbe.Exec.Command).To(ContainElement(ContainSubstring(redisAuthDir)))
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, secretLookupKey, secret)).To(Succeed())
+			oldPassword := string(secret.Data["password"])
+
+			By("Requesting a rotation through the annotation")
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			redis.Annotations = map[string]string{rotatePasswordAnnotation: "true"}
+			redis.Spec.PasswordRotation.GracePeriod = metav1.Duration{Duration: time.Hour}
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 50*time.Minute))
+
+			Expect(k8sClient.Get(ctx, secretLookupKey, secret)).To(Succeed())
+			Expect(string(secret.Data["password"])).NotTo(Equal(oldPassword))
+			Expect(string(secret.Data[previousPasswordKey])).To(Equal(oldPassword))
+			Expect(secret.Data).NotTo(HaveKey(nextPasswordKey))
+
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(redis.Annotations).NotTo(HaveKey(rotatePasswordAnnotation))
+			Expect(redis.Status.PasswordRotationStartTime).NotTo(BeNil())
+
+			By("Ending the grace period")
+			redis.Spec.PasswordRotation.GracePeriod = metav1.Duration{}
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, secretLookupKey, secret)).To(Succeed())
+			Expect(secret.Data).NotTo(HaveKey(previousPasswordKey))
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(redis.Status.LastPasswordRotationTime).NotTo(BeNil())
+			Expect(redis.Status.PasswordRotationStartTime).To(BeNil())
+
+			By("Keeping the pods running")
+			Expect(k8sClient.Get(ctx, redisLookupKey, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Annotations["redis.yazio.com/checksum"]).To(Equal(checksum))
+		})
+
+		It("should schedule rotations by interval", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.PasswordRotation = &redisv1alpha1.PasswordRotation{}
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour))}}
+
+			due, wait := rotationDue(redis, secret, time.Now())
+			Expect(due).To(BeFalse())
+			Expect(wait).To(BeZero())
+
+			redis.Spec.PasswordRotation.Interval = &metav1.Duration{Duration: 3 * time.Hour}
+			due, wait = rotationDue(redis, secret, time.Now())
+			Expect(due).To(BeFalse())
+			Expect(wait).To(BeNumerically("~", 2*time.Hour, time.Minute))
+
+			redis.Status.LastPasswordRotationTime = &metav1.Time{Time: time.Now().Add(-4 * time.Hour)}
+			due, _ = rotationDue(redis, secret, time.Now())
+			Expect(due).To(BeTrue())
+		})
+	})
+
+	Context("When serving TLS", func() {
+		const (
+			resourceName      = "test-tls"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with TLS")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.TLS = &redisv1alpha1.TLS{AuthClients: true}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should issue a certificate and serve TLS only", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 300*24*time.Hour))
+
+			By("Checking the generated TLS secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-tls", Namespace: resourceNamespace}, secret)).To(Succeed())
+			Expect(secret.Type).To(Equal(corev1.SecretTypeTLS))
+			cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cert.DNSNames).To(ContainElements("test-tls-service", "test-tls-service.default.svc"))
+			Expect(controllerReconciler.clientTLSConfig(&redisv1alpha1.Redis{ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: resourceNamespace}, Spec: redisv1alpha1.RedisSpec{TLS: &redisv1alpha1.TLS{}}})).NotTo(BeNil())
+
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(redis.Status.TLSCertificateNotAfter).NotTo(BeNil())
+
+			By("Checking the Deployment serves TLS from the secret")
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			container := deployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("--tls-port", "--tls-auth-clients", "yes"))
+			Expect(container.Args[:2]).To(Equal([]string{"--port", "0"}))
+			Expect(container.ReadinessProbe.Exec.Command).To(ContainElement("--tls"))
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("Name", tlsVolumeName)))
+		})
+
+		It("should renew certificates before expiry and keep the CA", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Mode = redisv1alpha1.ModeReplication
+			dnsNames := tlsDNSNames(redis)
+			Expect(dnsNames).To(ContainElement("*.test-tls-headless.default.svc"))
+
+			issued := time.Now().Add(-340 * 24 * time.Hour)
+			data, err := issueCertificate(redis, nil, dnsNames, issued)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(certificateNeedsRenewal(data[corev1.TLSCertKey], dnsNames, issued)).To(BeFalse())
+			Expect(certificateNeedsRenewal(data[corev1.TLSCertKey], dnsNames, time.Now())).To(BeTrue())
+			Expect(certificateNeedsRenewal(data[corev1.TLSCertKey], tlsDNSNames(newTestRedis(resourceName, resourceNamespace)), issued)).To(BeTrue())
+
+			renewed, err := issueCertificate(redis, data, dnsNames, time.Now())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(renewed[caCertKey]).To(Equal(data[caCertKey]))
+			Expect(renewed[corev1.TLSCertKey]).NotTo(Equal(data[corev1.TLSCertKey]))
+		})
+	})
+
+	Context("When autoscaling replicas", func() {
+		const (
+			resourceName      = "test-autoscaling"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with autoscaling")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Replicas = ptr.To(int32(2))
+			redis.Spec.Mode = redisv1alpha1.ModeReplication
+			redis.Spec.Autoscaling = &redisv1alpha1.Autoscaling{MinReplicas: 2, MaxReplicas: 5, TargetMemoryUtilizationPercentage: ptr.To(int32(70))}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should target the Redis scale subresource with an autoscaler", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the HorizontalPodAutoscaler")
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, hpa)).To(Succeed())
+			Expect(hpa.Spec.ScaleTargetRef).To(Equal(autoscalingv2.CrossVersionObjectReference{
+				APIVersion: redisv1alpha1.GroupVersion.String(), Kind: "Redis", Name: resourceName,
+			}))
+			Expect(*hpa.Spec.MinReplicas).To(Equal(int32(2)))
+			Expect(hpa.Spec.MaxReplicas).To(Equal(int32(5)))
+			Expect(hpa.Spec.Metrics).To(HaveLen(1))
+			Expect(hpa.Spec.Metrics[0].Resource.Name).To(Equal(corev1.ResourceMemory))
+
+			By("Checking the status exposes the selector for the scale subresource")
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(redis.Status.Selector).To(Equal("app=redis,redis_cr=test-autoscaling"))
+
+			By("Scaling the Redis through the scale subresource")
+			scale := &autoscalingv1.Scale{}
+			Expect(k8sClient.SubResource("scale").Get(ctx, redis, scale)).To(Succeed())
+			Expect(scale.Spec.Replicas).To(Equal(int32(2)))
+			scale.Spec.Replicas = 4
+			Expect(k8sClient.SubResource("scale").Update(ctx, redis, client.WithSubResourceBody(scale))).To(Succeed())
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(*redis.Spec.Replicas).To(Equal(int32(4)))
+
+			By("Removing the autoscaler once autoscaling is disabled")
+			redis.Spec.Autoscaling = nil
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, redisLookupKey, &autoscalingv2.HorizontalPodAutoscaler{}))).To(BeTrue())
+		})
+	})
+
+	Context("When summarizing the status", func() {
+		It("should derive the phase from the ready replicas", func() {
+			Expect(redisPhase(0, 3, false)).To(Equal(redisv1alpha1.PhasePending))
+			Expect(redisPhase(2, 3, true)).To(Equal(redisv1alpha1.PhaseUpdating))
+			Expect(redisPhase(2, 3, false)).To(Equal(redisv1alpha1.PhaseDegraded))
+			Expect(redisPhase(3, 3, false)).To(Equal(redisv1alpha1.PhaseReady))
+		})
+
+		It("should report the role and readiness of every pod", func() {
+			redis := newTestRedis("status", "default")
+			redis.Spec.Mode = redisv1alpha1.ModeReplication
+			pods := []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "status-0", Labels: map[string]string{roleLabel: rolePrimary}},
+					Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+				},
+				{ObjectMeta: metav1.ObjectMeta{Name: "status-1", Labels: map[string]string{roleLabel: roleReplica}}},
+			}
+			Expect(podStatuses(redis, pods)).To(Equal([]redisv1alpha1.RedisPodStatus{
+				{Name: "status-0", Role: rolePrimary, Ready: true},
+				{Name: "status-1", Role: roleReplica},
+			}))
+
+			redis.Spec.Mode = redisv1alpha1.ModeStandalone
+			Expect(podStatuses(redis, pods)[1].Role).To(Equal(rolePrimary))
+		})
+	})
+
+	Context("When scheduling backups", func() {
+		const (
+			resourceName      = "test-scheduled-backup"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with a backup schedule")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Backup = &redisv1alpha1.ScheduledBackup{
+				Schedule:    "*/5 * * * *",
+				Retention:   redisv1alpha1.BackupRetention{KeepLast: 2},
+				Destination: redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"}},
+			}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.DeleteAllOf(ctx, &redisv1alpha1.RedisBackup{}, client.InNamespace(resourceNamespace),
+				client.MatchingLabels{scheduledBackupLabel: resourceName})).To(Succeed())
+
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should catch up on a missed schedule and report the last failure", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+
+			By("Recording a failed backup scheduled an hour ago")
+			failed := scheduledBackupForRedis(redis, time.Now().UTC().Add(-time.Hour).Truncate(time.Minute))
+			Expect(k8sClient.Create(ctx, failed)).To(Succeed())
+			failed.Status.Phase = redisv1alpha1.BackupPhaseFailed
+			failed.Status.Conditions = []metav1.Condition{{
+				Type: conditionCompleted, Status: metav1.ConditionFalse, Reason: "JobFailed", Message: "upload failed", LastTransitionTime: metav1.Now(),
+			}}
+			Expect(k8sClient.Status().Update(ctx, failed)).To(Succeed())
+
+			delay, err := controllerReconciler.reconcileScheduledBackups(ctx, redis)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delay).To(BeNumerically("<=", 5*time.Minute))
+
+			By("Checking a single backup was created for the most recent schedule time")
+			backupList := &redisv1alpha1.RedisBackupList{}
+			Expect(k8sClient.List(ctx, backupList, client.MatchingLabels{scheduledBackupLabel: resourceName})).To(Succeed())
+			Expect(backupList.Items).To(HaveLen(2))
+			backups, err := controllerReconciler.scheduledBackups(ctx, redis)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backups[0].Spec.DeletionPolicy).To(Equal(redisv1alpha1.BackupDeletionPolicyDelete))
+			Expect(backups[0].Spec.Destination.PVC.ClaimName).To(Equal("backups"))
+			Expect(backups[0].OwnerReferences).To(BeEmpty())
+			Expect(time.Since(scheduledTime(&backups[0]))).To(BeNumerically("<", 5*time.Minute))
+
+			condition := meta.FindStatusCondition(redis.Status.Conditions, conditionBackupSucceeded)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Message).To(ContainSubstring("upload failed"))
+
+			By("Not taking another backup while one is running")
+			_, err = controllerReconciler.reconcileScheduledBackups(ctx, redis)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.List(ctx, backupList, client.MatchingLabels{scheduledBackupLabel: resourceName})).To(Succeed())
+			Expect(backupList.Items).To(HaveLen(2))
+		})
+
+		It("should find the most recent missed schedule time", func() {
+			schedule, err := cron.ParseStandard("0 3 * * *")
+			Expect(err).NotTo(HaveOccurred())
+			last := time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC)
+			Expect(lastScheduleTime(schedule, last, last.Add(time.Hour))).To(BeZero())
+			Expect(lastScheduleTime(schedule, last, time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC))).
+				To(Equal(time.Date(2025, 3, 4, 3, 0, 0, 0, time.UTC)))
+		})
+
+		It("should bound the names of backups and their Jobs", func() {
+			redis := newTestRedis(strings.Repeat("a", 60), resourceNamespace)
+			redis.Spec.Backup = &redisv1alpha1.ScheduledBackup{Destination: redisv1alpha1.BackupDestination{
+				PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"},
+			}}
+			scheduled := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			first := scheduledBackupForRedis(redis, scheduled)
+			second := scheduledBackupForRedis(redis, scheduled.Add(time.Minute))
+			Expect(len(first.Name)).To(BeNumerically("<=", 63))
+			Expect(first.Name).NotTo(Equal(second.Name))
+			Expect(len(boundedName(first.Name, "-delete"))).To(BeNumerically("<=", 63))
+			Expect(boundedName(first.Name, "-delete")).NotTo(Equal(boundedName(second.Name, "-delete")))
+			Expect(boundedName("test-backup", "-delete")).To(Equal("test-backup-delete"))
+		})
+
+		It("should prune completed backups outside the retention policy", func() {
+			backup := func(name string, scheduled time.Time, phase redisv1alpha1.BackupPhase) redisv1alpha1.RedisBackup {
+				return redisv1alpha1.RedisBackup{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{scheduledTimeAnnotation: scheduled.Format(time.RFC3339)}},
+					Status:     redisv1alpha1.RedisBackupStatus{Phase: phase},
+				}
+			}
+			day := func(d, hour int) time.Time { return time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC) }
+			// Sorted by schedule time, the most recent first. March 10 2025 is a Monday.
+			backups := []redisv1alpha1.RedisBackup{
+				backup("running", day(12, 12), redisv1alpha1.BackupPhaseUploading),
+				backup("12-noon", day(12, 0), redisv1alpha1.BackupPhaseCompleted),
+				backup("11-evening", day(11, 18), redisv1alpha1.BackupPhaseCompleted),
+				backup("11-failed", day(11, 12), redisv1alpha1.BackupPhaseFailed),
+				backup("11-morning", day(11, 6), redisv1alpha1.BackupPhaseCompleted),
+				backup("10", day(10, 0), redisv1alpha1.BackupPhaseCompleted),
+				backup("09", day(9, 0), redisv1alpha1.BackupPhaseCompleted),
+				backup("02", day(2, 0), redisv1alpha1.BackupPhaseCompleted),
+			}
+			names := func(pruned []*redisv1alpha1.RedisBackup) []string {
+				var result []string
+				for _, backup := range pruned {
+					result = append(result, backup.Name)
+				}
+				return result
+			}
+
+			Expect(names(backupsToPrune(backups, redisv1alpha1.BackupRetention{}))).To(Equal([]string{"11-failed"}))
+			Expect(names(backupsToPrune(backups, redisv1alpha1.BackupRetention{KeepLast: 2}))).
+				To(Equal([]string{"11-failed", "11-morning", "10", "09", "02"}))
+			Expect(names(backupsToPrune(backups, redisv1alpha1.BackupRetention{KeepDaily: 3}))).
+				To(Equal([]string{"11-failed", "11-morning", "09", "02"}))
+			Expect(names(backupsToPrune(backups, redisv1alpha1.BackupRetention{KeepLast: 1, KeepWeekly: 3}))).
+				To(Equal([]string{"11-evening", "11-failed", "11-morning", "10"}))
+		})
+	})
+
+	Context("When restoring from a backup", func() {
+		const (
+			resourceName      = "test-restore"
+			backupName        = "test-restore-source"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+		backupLookupKey := types.NamespacedName{Name: backupName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis restoring a backup")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Restore = &redisv1alpha1.Restore{BackupName: backupName}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			backup := &redisv1alpha1.RedisBackup{}
+			if err := k8sClient.Get(ctx, backupLookupKey, backup); err == nil {
+				Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+			}
+
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should hold the pods back until the backup completed and then restore it", func() {
+			controllerReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(RequeueDelay))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, redisLookupKey, &appsv1.Deployment{}))).To(BeTrue())
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(meta.FindStatusCondition(redis.Status.Conditions, conditionRestored).Reason).To(Equal("BackupNotFound"))
+
+			By("Completing the backup on a claim")
+			backup := &redisv1alpha1.RedisBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: backupName, Namespace: resourceNamespace},
+				Spec: redisv1alpha1.RedisBackupSpec{
+					RedisName:   "test-restore-origin",
+					Destination: redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+			backup.Status.Phase = redisv1alpha1.BackupPhaseCompleted
+			backup.Status.Location = backupLocation(backup)
+			backup.Status.Checksum = "sha256:0123"
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the init containers restore the snapshot into the data directory")
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, deployment)).To(Succeed())
+			podSpec := deployment.Spec.Template.Spec
+			Expect(podSpec.Containers[0].VolumeMounts).To(ContainElement(HaveField("MountPath", redisDataDir)))
+			Expect(podSpec.InitContainers).To(HaveLen(2))
+			fetch, verify := podSpec.InitContainers[0], podSpec.InitContainers[1]
+			Expect(fetch.Name).To(Equal(restoreFetchContainer))
+			Expect(fetch.Command[2]).To(ContainSubstring("cp /backup/test-restore-origin/test-restore-source.rdb /restore/dump.rdb"))
+			Expect(verify.Name).To(Equal(restoreContainer))
+			Expect(verify.Command[2]).To(ContainSubstring("redis-check-rdb"))
+			Expect(verify.Command[2]).To(ContainSubstring(restoredCheck))
+			Expect(verify.Image).To(Equal(redisv1alpha1.ImageWithTag(redis.Spec.Image)))
+			Expect(podSpec.Volumes).To(ContainElement(HaveField("ConfigMap.Name", restoredConfigMapName(redis))))
+			Expect(verify.Env).To(ContainElement(corev1.EnvVar{Name: "RESTORE_CHECKSUM", Value: "sha256:0123"}))
+			Expect(podSpec.Volumes).To(ContainElement(HaveField("PersistentVolumeClaim.ClaimName", "backups")))
+
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(meta.FindStatusCondition(redis.Status.Conditions, conditionRestored).Reason).To(Equal("Restoring"))
+
+			By("Rejecting changes to the restore source")
+			redis.Spec.Restore.BackupName = "other"
+			Expect(k8sClient.Update(ctx, redis)).NotTo(Succeed())
+
+			By("Marking the restore as finished so restarted pods skip it")
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			meta.SetStatusCondition(&redis.Status.Conditions, metav1.Condition{
+				Type: conditionRestored, Status: metav1.ConditionTrue, Reason: "Restored", Message: "Restored 18 bytes",
+			})
+			Expect(k8sClient.Status().Update(ctx, redis)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			marker := &corev1.ConfigMap{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: restoredConfigMapName(redis), Namespace: resourceNamespace}, marker)).To(Succeed())
+			Expect(marker.Data).To(HaveKeyWithValue(restoredKey, "true"))
+			Expect(k8sClient.Get(ctx, redisLookupKey, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.InitContainers).To(HaveLen(2))
+		})
+
+		It("should fetch s3:// and http URLs", func() {
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			redis := newTestRedis("restore-url", resourceNamespace)
+			redis.Spec.Restore = &redisv1alpha1.Restore{
+				URL: "s3://dumps/prod/dump.rdb",
+				S3:  &redisv1alpha1.S3Source{Endpoint: "http://minio:9000", CredentialsSecretName: "minio-credentials", Image: "minio/mc"},
+			}
+			source, err := controllerReconciler.resolveRestore(ctx, redis)
+			Expect(err).NotTo(HaveOccurred())
+			fetch := withRestore(podTemplateForRedis(redis), redis, source).Spec.InitContainers[0]
+			Expect(fetch.Image).To(Equal("minio/mc"))
+			Expect(fetch.Env).To(ContainElements(
+				corev1.EnvVar{Name: "S3_BUCKET", Value: "dumps"},
+				corev1.EnvVar{Name: "S3_KEY", Value: "prod/dump.rdb"},
+			))
+
+			redis.Spec.Restore = &redisv1alpha1.Restore{URL: "https://example.com/dump.rdb"}
+			source, err = controllerReconciler.resolveRestore(ctx, redis)
+			Expect(err).NotTo(HaveOccurred())
+			fetch = withRestore(podTemplateForRedis(redis), redis, source).Spec.InitContainers[0]
+			Expect(fetch.Image).To(Equal(restoreHTTPImage))
+			Expect(fetch.Env).To(Equal([]corev1.EnvVar{{Name: "RESTORE_URL", Value: "https://example.com/dump.rdb"}}))
+		})
+
+		It("should not allow restoring into an existing instance", func() {
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			redis.Spec.Restore = nil
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+			redis.Spec.Restore = &redisv1alpha1.Restore{URL: "https://example.com/dump.rdb"}
+			Expect(k8sClient.Update(ctx, redis)).NotTo(Succeed())
+		})
+	})
+
+	Context("When deleting a resource", func() {
+		const resourceNamespace = "default"
+
+		ctx := context.Background()
+
+		// createWithClaim creates the Redis, reconciles it once to create the password Secret and adds a data
+		// claim owned by the Redis.
+		createWithClaim := func(redis *redisv1alpha1.Redis) *corev1.PersistentVolumeClaim {
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(redis)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(redis), redis)).To(Succeed())
+
+			claim := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "data-" + redis.Name + "-0",
+					Namespace: resourceNamespace,
+					Labels:    labelsForRedis(redis.Name),
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+					},
+				},
+			}
+			Expect(controllerutil.SetOwnerReference(redis, claim, k8sClient.Scheme())).To(Succeed())
+			Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			return claim
+		}
+
+		It("should orphan the password Secret and the data claims with Retain", func() {
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			redis := newTestRedis("test-retain", resourceNamespace)
+			redis.Spec.DeletionPolicy = redisv1alpha1.DeletionPolicyRetain
+			claim := createWithClaim(redis)
+
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(redis)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(redis), redis))).To(BeTrue())
+
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "test-retain-password", Namespace: resourceNamespace}, secret)).To(Succeed())
+			Expect(secret.OwnerReferences).To(BeEmpty())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)).To(Succeed())
+			Expect(claim.OwnerReferences).To(BeEmpty())
+			Expect(claim.DeletionTimestamp).To(BeNil())
+
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+		})
+
+		It("should delete the data claims with Delete", func() {
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			redis := newTestRedis("test-delete", resourceNamespace)
+			claim := createWithClaim(redis)
+			Expect(redis.Spec.DeletionPolicy).To(Equal(redisv1alpha1.DeletionPolicyDelete))
+
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(redis)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(redis), redis))).To(BeTrue())
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)
+			Expect(errors.IsNotFound(err) || claim.DeletionTimestamp != nil).To(BeTrue())
+		})
+
+		It("should hold the finalizer until the final snapshot completed with Snapshot", func() {
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			redis := newTestRedis("test-final-snapshot", resourceNamespace)
+			redis.Spec.DeletionPolicy = redisv1alpha1.DeletionPolicySnapshot
+			redis.Spec.Backup = &redisv1alpha1.ScheduledBackup{
+				Schedule:    "@daily",
+				Suspend:     true,
+				Destination: redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"}},
+			}
+			claim := createWithClaim(redis)
+			redisLookupKey := client.ObjectKeyFromObject(redis)
+
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(RequeueDelay))
+
+			By("Checking the final backup was created and the claim is kept meanwhile")
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			Expect(meta.FindStatusCondition(redis.Status.Conditions, conditionFinalSnapshot).Reason).To(Equal("Snapshotting"))
+			backupList := &redisv1alpha1.RedisBackupList{}
+			Expect(k8sClient.List(ctx, backupList, client.MatchingLabels{finalBackupLabel: redis.Name})).To(Succeed())
+			Expect(backupList.Items).To(HaveLen(1))
+			backup := &backupList.Items[0]
+			Expect(backup.OwnerReferences).To(BeEmpty())
+			Expect(backup.Spec.Destination.PVC.ClaimName).To(Equal("backups"))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), claim)).To(Succeed())
+			Expect(claim.DeletionTimestamp).To(BeNil())
+
+			By("Completing the final backup")
+			backup.Status.Phase = redisv1alpha1.BackupPhaseCompleted
+			Expect(k8sClient.Status().Update(ctx, backup)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, redisLookupKey, redis))).To(BeTrue())
+
+			Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+		})
+	})
+
+	Context("When exporting metrics", func() {
+		const (
+			resourceName      = "test-exporter"
+			resourceNamespace = "default"
+
+			timeout  = time.Second * 20
+			interval = time.Millisecond * 250
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind Redis with the exporter")
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Monitoring = &redisv1alpha1.Monitoring{Exporter: &redisv1alpha1.Exporter{
+				Enabled:   true,
+				ExtraArgs: []string{"--include-system-metrics"},
+			}}
+			Expect(k8sClient.Create(ctx, redis)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, resource)).To(Succeed())
+
+			By("Cleanup the specific resource instance Redis")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should add the sidecar and expose the metrics port", func() {
+			controllerReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the sidecar of the Deployment")
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, redisLookupKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			containers := deployment.Spec.Template.Spec.Containers
+			Expect(containers).To(HaveLen(2))
+			exporter := containers[1]
+			Expect(exporter.Name).To(Equal(exporterContainerName))
+			Expect(exporter.Image).To(Equal("oliver006/redis_exporter"))
+			Expect(exporter.Args).To(Equal([]string{
+				"--redis.addr=redis://localhost:6379",
+				"--web.listen-address=:9121",
+				"--include-system-metrics",
+			}))
+			Expect(exporter.Ports).To(ContainElement(HaveField("Name", metricsPortName)))
+			Expect(exporter.Env).To(ContainElement(HaveField("ValueFrom.SecretKeyRef.Name", resourceName+"-password")))
+
+			By("Checking the metrics port of the Service")
+			service := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: resourceName + "-service", Namespace: resourceNamespace}, service)).To(Succeed())
+			Expect(service.Spec.Ports).To(ContainElement(SatisfyAll(
+				HaveField("Name", metricsPortName),
+				HaveField("Port", int32(redisv1alpha1.ExporterPort)),
+			)))
+
+			By("Disabling the exporter")
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			redis.Spec.Monitoring.Exporter.Enabled = false
+			Expect(k8sClient.Update(ctx, redis)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, redisLookupKey, deployment)).To(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed())
+			Expect(service.Spec.Ports).To(HaveLen(1))
+		})
+
+		It("should detect drift on the sidecar", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.Monitoring = &redisv1alpha1.Monitoring{Exporter: &redisv1alpha1.Exporter{Enabled: true, Image: "oliver006/redis_exporter"}}
+			before := podTemplateForRedis(redis)
+			Expect(templatesMatch(&before, ptr.To(podTemplateForRedis(redis)))).To(BeTrue())
+
+			redis.Spec.Monitoring.Exporter.Image = "oliver006/redis_exporter:v1.67.0"
+			Expect(templatesMatch(&before, ptr.To(podTemplateForRedis(redis)))).To(BeFalse())
+
+			redis.Spec.Monitoring.Exporter.Image = "oliver006/redis_exporter"
+			redis.Spec.Monitoring.Exporter.Resources = corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")},
+			}
+			Expect(templatesMatch(&before, ptr.To(podTemplateForRedis(redis)))).To(BeFalse())
+		})
+
+		It("should read a password file that follows the rotation", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.PasswordRotation = &redisv1alpha1.PasswordRotation{GracePeriod: metav1.Duration{Duration: 10 * time.Minute}}
+			redis.Spec.Monitoring = &redisv1alpha1.Monitoring{Exporter: &redisv1alpha1.Exporter{Enabled: true}}
+			template := podTemplateForRedis(redis)
+
+			exporter := findContainer(template.Spec.Containers, exporterContainerName)
+			Expect(exporter).NotTo(BeNil())
+			Expect(exporter.Env).To(BeEmpty())
+			Expect(exporter.Args).To(ContainElement("--redis.password-file=/etc/redis-exporter/exporter-password-file"))
+			Expect(exporter.VolumeMounts).To(ContainElement(HaveField("Name", exporterAuthVolumeName)))
+			Expect(template.Spec.Volumes).To(ContainElement(HaveField("Secret.Items", ContainElement(HaveField("Key", exporterPasswordFileKey)))))
+
+			secret := &corev1.Secret{Data: map[string][]byte{"password": []byte("old")}}
+			changed, err := setExporterPasswordFile(redis, secret, "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(string(secret.Data[exporterPasswordFileKey])).To(Equal(`{"redis://localhost:6379":"new"}`))
+			changed, err = setExporterPasswordFile(redis, secret, "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+
+			By("disabling the exporter")
+			redis.Spec.Monitoring = nil
+			changed, err = setExporterPasswordFile(redis, secret, "new")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(secret.Data).NotTo(HaveKey(exporterPasswordFileKey))
+		})
+
+		It("should scrape over TLS with the client certificate", func() {
+			redis := newTestRedis(resourceName, resourceNamespace)
+			redis.Spec.TLS = &redisv1alpha1.TLS{AuthClients: true}
+			redis.Spec.Monitoring = &redisv1alpha1.Monitoring{Exporter: &redisv1alpha1.Exporter{Enabled: true}}
+			template := podTemplateForRedis(redis)
+
+			exporter := findContainer(template.Spec.Containers, exporterContainerName)
+			Expect(exporter).NotTo(BeNil())
+			Expect(exporter.Image).To(Equal(defaultExporterImage))
+			Expect(exporter.Args).To(ContainElements(
+				"--redis.addr=rediss://localhost:6379",
+				"--tls-ca-cert-file=/etc/redis-tls/ca.crt",
+				"--tls-client-cert-file=/etc/redis-tls/tls.crt",
+			))
+			Expect(exporter.VolumeMounts).To(ContainElement(HaveField("Name", tlsVolumeName)))
+		})
+	})
+
+	Context("When electing a replication primary", func() {
+		runningPod := func(name string) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status: corev1.PodStatus{
+					PodIP: "10.0.0.1",
+					ContainerStatuses: []corev1.ContainerStatus{{
+						Name:  "redis",
+						State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					}},
+				},
+			}
+		}
+
+		It("should keep the recorded primary while its pod exists", func() {
+			redis := newTestRedis("elect", "default")
+			redis.Status.Primary = "elect-1"
+			pods := []corev1.Pod{runningPod("elect-0"), {ObjectMeta: metav1.ObjectMeta{Name: "elect-1"}}}
+			Expect(electPrimary(redis, pods).Name).To(Equal("elect-1"))
+		})
+
+		It("should elect the lowest running ordinal otherwise", func() {
+			redis := newTestRedis("elect", "default")
+			redis.Status.Primary = "elect-5"
+			pods := []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "elect-0"}}, runningPod("elect-1"), runningPod("elect-2")}
+			Expect(electPrimary(redis, pods).Name).To(Equal("elect-1"))
+		})
+
+		It("should replace a primary that restarted without its data", func() {
+			infos := map[string]map[string]string{
+				"elect-0": {"role": "master", "master_repl_offset": "0"},
+				"elect-1": {"role": "slave", "master_repl_offset": "1200"},
+				"elect-2": {"role": "slave", "master_repl_offset": "1500"},
+			}
+			Expect(replicaAheadOfEmptyPrimary("elect-0", infos)).To(Equal("elect-2"))
+
+			infos["elect-0"]["master_repl_offset"] = "1500"
+			Expect(replicaAheadOfEmptyPrimary("elect-0", infos)).To(BeEmpty())
+
+			By("keeping a primary that loaded its keys from disk")
+			infos["elect-0"]["master_repl_offset"] = "0"
+			infos["elect-0"]["db0"] = "keys=10,expires=0,avg_ttl=0"
+			Expect(replicaAheadOfEmptyPrimary("elect-0", infos)).To(BeEmpty())
+
+			delete(infos["elect-0"], "db0")
+			infos["elect-1"]["master_repl_offset"] = "0"
+			infos["elect-2"]["master_repl_offset"] = "0"
+			Expect(replicaAheadOfEmptyPrimary("elect-0", infos)).To(BeEmpty())
+
+			By("only pointing replicas without data at an empty primary")
+			Expect(emptyPrimary(infos["elect-0"])).To(BeTrue())
+			Expect(mayHoldData(infos["elect-1"])).To(BeFalse())
+			Expect(mayHoldData(map[string]string{"role": "slave", "master_repl_offset": "1200"})).To(BeTrue())
+			Expect(mayHoldData(nil)).To(BeTrue())
+		})
+
+		It("should parse INFO replies", func() {
+			info := parseInfo("# Replication\r\nrole:slave\r\nmaster_host:elect-0.elect-headless.default.svc\r\nmaster_port:6379\r\n")
+			Expect(info).To(HaveKeyWithValue("role", "slave"))
+			Expect(info).To(HaveKeyWithValue("master_port", "6379"))
+		})
+	})
 })
+
+// newTestRedis returns a minimal Redis resource with the fields the API server does not default for us.
+func newTestRedis(name string, namespace string) *redisv1alpha1.Redis {
+	replicas := int32(1)
+	port := int32(6379)
+	return &redisv1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: redisv1alpha1.RedisSpec{
+			Replicas:           &replicas,
+			Image:              "bitnami/redis",
+			Port:               &port,
+			PasswordSecretName: name + "-password",
+			Service: redisv1alpha1.Service{
+				Name: name + "-service",
+				Type: string(corev1.ServiceTypeClusterIP),
+				Port: &port,
+			},
+		},
+	}
+}
@@ -0,0 +1,676 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// conditionCompleted is the condition type set on the RedisBackup status once the snapshot is stored.
+	conditionCompleted = "Completed"
+
+	// backupPollInterval is how often the operator checks whether BGSAVE has finished.
+	backupPollInterval = 2 * time.Second
+	// backupDumpContainer is the name of the container fetching the snapshot with redis-cli. It reports the
+	// size and checksum of the snapshot in its termination message.
+	backupDumpContainer = "dump"
+	// backupUploadContainer is the name of the container uploading the snapshot to S3.
+	backupUploadContainer = "upload"
+	// backupDeleteContainer is the name of the container deleting the stored snapshot.
+	backupDeleteContainer = "delete"
+	// backupDeleteImage is the image deleting snapshots stored on a claim.
+	backupDeleteImage = "busybox"
+	// backupVolumeName is the name of the volume the snapshot is written to.
+	backupVolumeName = "backup"
+	// backupDir is the directory the backup volume is mounted at.
+	backupDir = "/backup"
+)
+
+// RedisBackupReconciler reconciles a RedisBackup object
+type RedisBackupReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// backupResult is the termination message the dump container reports the snapshot with.
+type backupResult struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisbackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile takes the RDB snapshot declared by a RedisBackup. It triggers BGSAVE on the primary, waits for
+// LASTSAVE to advance past the start of the backup and then runs a Job fetching the snapshot with
+// redis-cli --rdb and storing it at the destination. Completed and failed backups are left alone.
+// Backups with the Delete deletion policy hold a finalizer until a Job deleted their stored snapshot.
+func (r *RedisBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	backup := &redisv1alpha1.RedisBackup{}
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		if errors.IsNotFound(err) {
+			return reconciled()
+		}
+		log.Error(err, "unable to fetch RedisBackup")
+		return requeueInstanceWithError(ctx, req.Name, req.Namespace, err)
+	}
+	if !backup.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(backup, FinalizerName) {
+			deleted, err := r.deleteSnapshot(ctx, backup)
+			if err != nil {
+				return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+			}
+			if !deleted {
+				// The Job watch picks the backup up again once the snapshot is deleted.
+				return reconciled()
+			}
+
+			controllerutil.RemoveFinalizer(backup, FinalizerName)
+			if err := r.Update(ctx, backup); err != nil {
+				return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+			}
+			log.Info("Finalizer removed from RedisBackup", "name", backup.Name)
+		}
+		return reconciled()
+	}
+
+	if backup.Spec.DeletionPolicy == redisv1alpha1.BackupDeletionPolicyDelete && !controllerutil.ContainsFinalizer(backup, FinalizerName) {
+		controllerutil.AddFinalizer(backup, FinalizerName)
+		if err := r.Update(ctx, backup); err != nil {
+			return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+		}
+	}
+	if backupFinished(backup) {
+		return reconciled()
+	}
+
+	redis := &redisv1alpha1.Redis{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.RedisName, Namespace: backup.Namespace}, redis); err != nil {
+		if !errors.IsNotFound(err) {
+			return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+		}
+		// The Redis watch picks the backup up again once the instance is created.
+		if err := r.setBackupPhase(ctx, backup, backup.DeepCopy(), redisv1alpha1.BackupPhasePending, "RedisNotFound",
+			fmt.Sprintf("Redis %s does not exist", backup.Spec.RedisName)); err != nil {
+			return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+		}
+		return reconciled()
+	}
+	if isClustered(redis) {
+		if err := r.failBackup(ctx, backup, backup.DeepCopy(), "ClusterModeUnsupported",
+			"Redis in cluster mode spreads its keys across shards and cannot be backed up from a single pod"); err != nil {
+			return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+		}
+		return reconciled()
+	}
+	var (
+		result ctrl.Result
+		err    error
+	)
+	switch backup.Status.Phase {
+	case redisv1alpha1.BackupPhaseSnapshotting:
+		result, err = r.awaitSnapshot(ctx, backup, redis)
+	case redisv1alpha1.BackupPhaseUploading:
+		result, err = r.awaitUpload(ctx, backup)
+	default:
+		result, err = r.startSnapshot(ctx, backup, redis)
+	}
+	if err != nil {
+		return requeueInstanceWithError(ctx, backup.Name, backup.Namespace, err)
+	}
+	return result, nil
+}
+
+// startSnapshot triggers BGSAVE on the pod the backup is taken from. BGSAVE SCHEDULE defers the save
+// while an AOF rewrite is running instead of failing.
+func (r *RedisBackupReconciler) startSnapshot(ctx context.Context, backup *redisv1alpha1.RedisBackup, redis *redisv1alpha1.Redis) (ctrl.Result, error) {
+	pod, err := r.backupSourcePod(ctx, redis)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pod == nil {
+		if err := r.setBackupPhase(ctx, backup, backup.DeepCopy(), redisv1alpha1.BackupPhasePending, "NoRunningPod",
+			fmt.Sprintf("Redis %s has no running primary", redis.Name)); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: RequeueDelay}, nil
+	}
+	password, err := readRedisPassword(ctx, r.Client, redis)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	tlsConfig, err := readClientTLSConfig(ctx, r.Client, redis)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	base := backup.DeepCopy()
+	backup.Status.StartTime = ptr.To(metav1.Now())
+	rdb := redisClientForPod(redis, pod, password, tlsConfig)
+	defer func() { _ = rdb.Close() }()
+	// A save that is already running completes after the backup started, so it advances LASTSAVE as well.
+	if err := rdb.Do(ctx, "BGSAVE", "SCHEDULE").Err(); err != nil && !strings.Contains(err.Error(), "already in progress") {
+		return ctrl.Result{}, fmt.Errorf("triggering BGSAVE on pod %s: %w", pod.Name, err)
+	}
+	backup.Status.Pod = pod.Name
+	r.Recorder.Event(backup, corev1.EventTypeNormal, "SnapshotStarted", fmt.Sprintf("Triggered BGSAVE on pod %s", pod.Name))
+	if err := r.setBackupPhase(ctx, backup, base, redisv1alpha1.BackupPhaseSnapshotting, "Snapshotting",
+		fmt.Sprintf("Waiting for BGSAVE to finish on pod %s", pod.Name)); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+}
+
+// awaitSnapshot waits for LASTSAVE on the source pod to reach the start of the backup and then creates
+// the Job storing the snapshot.
+func (r *RedisBackupReconciler) awaitSnapshot(ctx context.Context, backup *redisv1alpha1.RedisBackup, redis *redisv1alpha1.Redis) (ctrl.Result, error) {
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Status.Pod, Namespace: backup.Namespace}, pod); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	if !podRunning(pod) {
+		return ctrl.Result{}, r.failBackup(ctx, backup, backup.DeepCopy(), "PodLost",
+			fmt.Sprintf("Pod %s stopped before the snapshot was taken", backup.Status.Pod))
+	}
+	password, err := readRedisPassword(ctx, r.Client, redis)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	tlsConfig, err := readClientTLSConfig(ctx, r.Client, redis)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	rdb := redisClientForPod(redis, pod, password, tlsConfig)
+	defer func() { _ = rdb.Close() }()
+	lastSave, err := rdb.LastSave(ctx).Result()
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if lastSave < backup.Status.StartTime.Unix() {
+		info, err := redisInfo(ctx, rdb, "persistence")
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if info["rdb_bgsave_in_progress"] == "0" && info["aof_rewrite_in_progress"] == "0" && info["rdb_last_bgsave_status"] == "err" {
+			return ctrl.Result{}, r.failBackup(ctx, backup, backup.DeepCopy(), "SnapshotFailed",
+				fmt.Sprintf("BGSAVE failed on pod %s", pod.Name))
+		}
+		return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+	}
+
+	job, err := r.backupJob(backup, redis, pod)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Event(backup, corev1.EventTypeNormal, "CreatedJob", fmt.Sprintf("Created job %s storing the snapshot", job.Name))
+
+	base := backup.DeepCopy()
+	backup.Status.JobName = job.Name
+	backup.Status.Location = backupLocation(backup)
+	return ctrl.Result{}, r.setBackupPhase(ctx, backup, base, redisv1alpha1.BackupPhaseUploading, "Uploading",
+		fmt.Sprintf("Job %s stores the snapshot at %s", job.Name, backup.Status.Location))
+}
+
+// awaitUpload records the result of the backup Job once it finished. The Job watch picks the backup up again.
+func (r *RedisBackupReconciler) awaitUpload(ctx context.Context, backup *redisv1alpha1.RedisBackup) (ctrl.Result, error) {
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Status.JobName, Namespace: backup.Namespace}, job); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.failBackup(ctx, backup, backup.DeepCopy(), "JobLost",
+			fmt.Sprintf("Job %s was deleted before it finished", backup.Status.JobName))
+	}
+
+	if condition := jobCondition(job, batchv1.JobFailed); condition != nil {
+		return ctrl.Result{}, r.failBackup(ctx, backup, backup.DeepCopy(), "JobFailed",
+			fmt.Sprintf("Job %s failed: %s", job.Name, condition.Message))
+	}
+	if jobCondition(job, batchv1.JobComplete) == nil {
+		return reconciled()
+	}
+
+	result, err := r.backupJobResult(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, r.failBackup(ctx, backup, backup.DeepCopy(), "InvalidResult", err.Error())
+	}
+	base := backup.DeepCopy()
+	backup.Status.Size = result.Size
+	backup.Status.Checksum = result.Checksum
+	backup.Status.CompletionTime = ptr.To(metav1.Now())
+	r.Recorder.Event(backup, corev1.EventTypeNormal, "BackupCompleted",
+		fmt.Sprintf("Stored %d byte snapshot at %s", result.Size, backup.Status.Location))
+	return ctrl.Result{}, r.setBackupPhaseStatus(ctx, backup, base, redisv1alpha1.BackupPhaseCompleted, metav1.ConditionTrue, "Succeeded",
+		fmt.Sprintf("Snapshot stored at %s", backup.Status.Location))
+}
+
+// backupSourcePod returns the running pod to take the snapshot from: the primary of a replicated instance,
+// or the first running pod of a standalone one. It returns nil if there is none.
+func (r *RedisBackupReconciler) backupSourcePod(ctx context.Context, redis *redisv1alpha1.Redis) (*corev1.Pod, error) {
+	pods, err := listRedisPods(ctx, r.Client, redis)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		if !isReplicated(redis) || pod.Name == redis.Status.Primary {
+			return pod, nil
+		}
+	}
+	return nil, nil
+}
+
+// backupJobResult reads the size and checksum of the snapshot from the termination message of the dump
+// container of the succeeded pod of the Job.
+func (r *RedisBackupReconciler) backupJobResult(ctx context.Context, job *batchv1.Job) (*backupResult, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.InNamespace(job.Namespace), client.MatchingLabels(job.Spec.Template.Labels)); err != nil {
+		return nil, err
+	}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		statuses := append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if status.Name != backupDumpContainer || status.State.Terminated == nil {
+				continue
+			}
+			result := &backupResult{}
+			if err := json.Unmarshal([]byte(status.State.Terminated.Message), result); err != nil {
+				return nil, fmt.Errorf("parsing the result of pod %s: %w", pod.Name, err)
+			}
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("no succeeded pod of job %s reported a result", job.Name)
+}
+
+// backupJob returns the Job fetching the snapshot from the source pod with redis-cli --rdb. The dump
+// container writes the snapshot to the destination claim, or to a scratch volume the upload container
+// copies it from to S3 with the MinIO client.
+func (r *RedisBackupReconciler) backupJob(backup *redisv1alpha1.RedisBackup, redis *redisv1alpha1.Redis, pod *corev1.Pod) (*batchv1.Job, error) {
+	file := path.Join(backupDir, backupFilePath(backup))
+	script := fmt.Sprintf(`set -e
+mkdir -p %[1]s
+%[2]s -h "$REDIS_HOST" -p %[3]d --rdb %[4]s
+size=$(stat -c %%s %[4]s)
+checksum=$(sha256sum %[4]s | cut -d ' ' -f 1)
+printf '{"size":%%s,"checksum":"sha256:%%s"}' "$size" "$checksum" > /dev/termination-log
+`, path.Dir(file), strings.Join(redisv1alpha1.RedisCLI(redis), " "), *redis.Spec.Port, file)
+
+	dump := corev1.Container{
+		Name:    backupDumpContainer,
+		Image:   redisv1alpha1.ImageWithTag(redis.Spec.Image),
+		Command: []string{"sh", "-c", script},
+		Env: []corev1.EnvVar{
+			{Name: "REDIS_HOST", Value: pod.Status.PodIP},
+			{
+				Name: "REDISCLI_AUTH",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: redis.Spec.PasswordSecretName},
+						Key:                  redisv1alpha1.PasswordSecretKey(redis),
+					},
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{{Name: backupVolumeName, MountPath: backupDir}},
+	}
+
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever}
+	if hasTLS(redis) {
+		addTLSVolume(redis, &podSpec, &dump)
+	}
+	if claim := backup.Spec.Destination.PVC; claim != nil {
+		podSpec.Containers = []corev1.Container{dump}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: backupVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim.ClaimName},
+			},
+		})
+	} else {
+		podSpec.InitContainers = []corev1.Container{dump}
+		podSpec.Containers = []corev1.Container{s3UploadContainer(backup, file)}
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name:         backupVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+	}
+
+	labels := labelsForBackup(backup)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      boundedName(backup.Name, ""),
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(2)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(backup, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// s3UploadContainer returns the container copying the snapshot file to the S3 destination of the backup.
+func s3UploadContainer(backup *redisv1alpha1.RedisBackup, file string) corev1.Container {
+	s3 := backup.Spec.Destination.S3
+	insecure := ""
+	if s3.InsecureSkipVerify {
+		insecure = " --insecure"
+	}
+	script := fmt.Sprintf(`set -e
+mc%[1]s alias set backup "$S3_ENDPOINT" "$%[2]s" "$%[3]s"
+mc%[1]s cp %[4]s "backup/$S3_BUCKET/$S3_KEY"
+`, insecure, redisv1alpha1.BackupAccessKeyIDKey, redisv1alpha1.BackupSecretAccessKeyKey, file)
+
+	return corev1.Container{
+		Name:         backupUploadContainer,
+		Image:        s3.Image,
+		Command:      []string{"sh", "-c", script},
+		Env:          s3Env(backup),
+		VolumeMounts: []corev1.VolumeMount{{Name: backupVolumeName, MountPath: backupDir, ReadOnly: true}},
+	}
+}
+
+// s3Env returns the environment of a MinIO client container addressing the S3 object of the backup.
+func s3Env(backup *redisv1alpha1.RedisBackup) []corev1.EnvVar {
+	s3 := backup.Spec.Destination.S3
+	return s3ClientEnv(s3.Endpoint, s3.Bucket, s3ObjectKey(backup), s3.CredentialsSecretName)
+}
+
+// s3ClientEnv returns the environment of a MinIO client container addressing an S3 object with the
+// credentials of the given secret.
+func s3ClientEnv(endpoint string, bucket string, key string, credentialsSecretName string) []corev1.EnvVar {
+	credential := func(name string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: credentialsSecretName},
+					Key:                  name,
+				},
+			},
+		}
+	}
+	return []corev1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: endpoint},
+		{Name: "S3_BUCKET", Value: bucket},
+		{Name: "S3_KEY", Value: key},
+		// The MinIO client keeps its configuration in the home directory, which may not be writable.
+		{Name: "MC_CONFIG_DIR", Value: "/tmp/.mc"},
+		credential(redisv1alpha1.BackupAccessKeyIDKey),
+		credential(redisv1alpha1.BackupSecretAccessKeyKey),
+	}
+}
+
+// deleteSnapshot runs a Job deleting the stored snapshot of a deleted backup and reports whether it is gone.
+// A snapshot the Job fails to delete is reported with an event and left behind, so the backup can be removed.
+func (r *RedisBackupReconciler) deleteSnapshot(ctx context.Context, backup *redisv1alpha1.RedisBackup) (bool, error) {
+	if backup.Status.Location == "" {
+		return true, nil
+	}
+	if backup.Status.Phase == redisv1alpha1.BackupPhaseUploading {
+		// Wait for the backup Job, so it does not store the snapshot after it was deleted.
+		upload := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: backup.Status.JobName, Namespace: backup.Namespace}, upload)
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		if err == nil && jobCondition(upload, batchv1.JobComplete) == nil && jobCondition(upload, batchv1.JobFailed) == nil {
+			return false, nil
+		}
+	}
+
+	desired, err := r.deleteSnapshotJob(backup)
+	if err != nil {
+		return false, err
+	}
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: backup.Namespace}, job); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err := r.Create(ctx, desired); err != nil {
+			return false, err
+		}
+		r.Recorder.Event(backup, corev1.EventTypeNormal, "DeletingSnapshot", fmt.Sprintf("Created job %s deleting the snapshot at %s", desired.Name, backup.Status.Location))
+		return false, nil
+	}
+
+	if condition := jobCondition(job, batchv1.JobFailed); condition != nil {
+		r.Recorder.Event(backup, corev1.EventTypeWarning, "SnapshotDeletionFailed",
+			fmt.Sprintf("Job %s failed to delete the snapshot at %s, it has to be deleted manually: %s", job.Name, backup.Status.Location, condition.Message))
+		return true, nil
+	}
+	return jobCondition(job, batchv1.JobComplete) != nil, nil
+}
+
+// deleteSnapshotJob returns the Job deleting the stored snapshot of the backup, with the MinIO client for S3
+// or with rm from the destination claim.
+func (r *RedisBackupReconciler) deleteSnapshotJob(backup *redisv1alpha1.RedisBackup) (*batchv1.Job, error) {
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever}
+	if claim := backup.Spec.Destination.PVC; claim != nil {
+		podSpec.Containers = []corev1.Container{{
+			Name:         backupDeleteContainer,
+			Image:        backupDeleteImage,
+			Command:      []string{"rm", "-f", path.Join(backupDir, backupFilePath(backup))},
+			VolumeMounts: []corev1.VolumeMount{{Name: backupVolumeName, MountPath: backupDir}},
+		}}
+		podSpec.Volumes = []corev1.Volume{{
+			Name: backupVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim.ClaimName},
+			},
+		}}
+	} else {
+		s3 := backup.Spec.Destination.S3
+		insecure := ""
+		if s3.InsecureSkipVerify {
+			insecure = " --insecure"
+		}
+		script := fmt.Sprintf(`set -e
+mc%[1]s alias set backup "$S3_ENDPOINT" "$%[2]s" "$%[3]s"
+mc%[1]s rm "backup/$S3_BUCKET/$S3_KEY"
+`, insecure, redisv1alpha1.BackupAccessKeyIDKey, redisv1alpha1.BackupSecretAccessKeyKey)
+		podSpec.Containers = []corev1.Container{{
+			Name:    backupDeleteContainer,
+			Image:   s3.Image,
+			Command: []string{"sh", "-c", script},
+			Env:     s3Env(backup),
+		}}
+	}
+
+	labels := labelsForBackup(backup)
+	labels["app"] = "redis-backup-delete"
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      boundedName(backup.Name, "-delete"),
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: ptr.To(int32(2)),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+	if err := ctrl.SetControllerReference(backup, job, r.Scheme); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// setBackupPhase moves the backup to an unfinished phase and records why on the Completed condition.
+func (r *RedisBackupReconciler) setBackupPhase(ctx context.Context, backup *redisv1alpha1.RedisBackup, base *redisv1alpha1.RedisBackup, phase redisv1alpha1.BackupPhase, reason string, message string) error {
+	return r.setBackupPhaseStatus(ctx, backup, base, phase, metav1.ConditionFalse, reason, message)
+}
+
+// failBackup marks the backup as failed. Failed backups are not retried; a new RedisBackup has to be created.
+func (r *RedisBackupReconciler) failBackup(ctx context.Context, backup *redisv1alpha1.RedisBackup, base *redisv1alpha1.RedisBackup, reason string, message string) error {
+	r.Recorder.Event(backup, corev1.EventTypeWarning, reason, message)
+	return r.setBackupPhaseStatus(ctx, backup, base, redisv1alpha1.BackupPhaseFailed, metav1.ConditionFalse, reason, message)
+}
+
+// setBackupPhaseStatus patches the phase and the Completed condition of the backup if either changed.
+func (r *RedisBackupReconciler) setBackupPhaseStatus(ctx context.Context, backup *redisv1alpha1.RedisBackup, base *redisv1alpha1.RedisBackup, phase redisv1alpha1.BackupPhase, status metav1.ConditionStatus, reason string, message string) error {
+	backup.Status.Phase = phase
+	meta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:               conditionCompleted,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: backup.Generation,
+	})
+	if reflect.DeepEqual(base.Status, backup.Status) {
+		return nil
+	}
+	return r.Status().Patch(ctx, backup, client.MergeFrom(base))
+}
+
+// backupFinished reports whether the backup completed or failed.
+func backupFinished(backup *redisv1alpha1.RedisBackup) bool {
+	return backup.Status.Phase == redisv1alpha1.BackupPhaseCompleted || backup.Status.Phase == redisv1alpha1.BackupPhaseFailed
+}
+
+// jobCondition returns the given condition of a Job if it is true.
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i := range job.Status.Conditions {
+		if condition := &job.Status.Conditions[i]; condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return condition
+		}
+	}
+	return nil
+}
+
+// backupFilePath returns the path of the snapshot relative to the destination: <redis>/<backup>.rdb,
+// below spec.destination.pvc.path for claims.
+func backupFilePath(backup *redisv1alpha1.RedisBackup) string {
+	file := path.Join(backup.Spec.RedisName, backup.Name+".rdb")
+	if claim := backup.Spec.Destination.PVC; claim != nil {
+		return path.Join(strings.TrimPrefix(claim.Path, "/"), file)
+	}
+	return file
+}
+
+// s3ObjectKey returns the object key the snapshot is uploaded to.
+func s3ObjectKey(backup *redisv1alpha1.RedisBackup) string {
+	return path.Join(strings.Trim(backup.Spec.Destination.S3.Prefix, "/"), backupFilePath(backup))
+}
+
+// backupLocation returns the URL of the stored snapshot.
+func backupLocation(backup *redisv1alpha1.RedisBackup) string {
+	if claim := backup.Spec.Destination.PVC; claim != nil {
+		return fmt.Sprintf("pvc://%s/%s", claim.ClaimName, backupFilePath(backup))
+	}
+	return fmt.Sprintf("s3://%s/%s", backup.Spec.Destination.S3.Bucket, s3ObjectKey(backup))
+}
+
+// labelsForBackup returns the labels of the backup Job and its pods.
+func labelsForBackup(backup *redisv1alpha1.RedisBackup) map[string]string {
+	return map[string]string{"app": "redis-backup", "redis_cr": backup.Spec.RedisName, "redis_backup": boundedName(backup.Name, "")}
+}
+
+// boundedName returns name with suffix appended, shortened to the 63 characters of a label value, as Job
+// names are copied into the job-name label of their pods. Longer names keep a prefix and end in a hash of the
+// full name, so they stay unique.
+func boundedName(name string, suffix string) string {
+	full := name + suffix
+	if len(full) <= validation.DNS1123LabelMaxLength {
+		return full
+	}
+	sum := sha256.Sum256([]byte(full))
+	hash := hex.EncodeToString(sum[:])[:8]
+	return full[:validation.DNS1123LabelMaxLength-len(hash)-1] + "-" + hash
+}
+
+// backupsForRedis maps a Redis instance to the unfinished RedisBackups waiting for it.
+func (r *RedisBackupReconciler) backupsForRedis(ctx context.Context, obj client.Object) []reconcile.Request {
+	backupList := &redisv1alpha1.RedisBackupList{}
+	if err := r.List(ctx, backupList, client.InNamespace(obj.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list RedisBackups")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, backup := range backupList.Items {
+		if backup.Spec.RedisName == obj.GetName() && !backupFinished(&backup) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&backup)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RedisBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&redisv1alpha1.RedisBackup{}).
+		Owns(&batchv1.Job{}).
+		Watches(&redisv1alpha1.Redis{}, handler.EnqueueRequestsFromMapFunc(r.backupsForRedis)).
+		Named("redisbackup").
+		Complete(r)
+}
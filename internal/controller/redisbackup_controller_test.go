@@ -0,0 +1,194 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+var _ = Describe("RedisBackup Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			redisName         = "test-backup-redis"
+			resourceName      = "test-backup"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: redisName, Namespace: resourceNamespace}
+		backupLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the Redis to back up")
+			Expect(k8sClient.Create(ctx, newTestRedis(redisName, resourceNamespace))).To(Succeed())
+
+			By("creating the custom resource for the Kind RedisBackup")
+			backup := &redisv1alpha1.RedisBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: resourceNamespace},
+				Spec: redisv1alpha1.RedisBackupSpec{
+					RedisName: redisName,
+					Destination: redisv1alpha1.BackupDestination{S3: &redisv1alpha1.S3Destination{
+						Endpoint:              "http://minio:9000",
+						Bucket:                "redis-backups",
+						Prefix:                "/nightly/",
+						CredentialsSecretName: "minio-credentials",
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			backup := &redisv1alpha1.RedisBackup{}
+			Expect(k8sClient.Get(ctx, backupLookupKey, backup)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, backup)).To(Succeed())
+
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+
+			By("Cleanup the Redis instance")
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			redisReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := redisReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should wait for a running pod to snapshot", func() {
+			redisReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			_, err := redisReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			controllerReconciler := &RedisBackupReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: backupLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(RequeueDelay))
+
+			backup := &redisv1alpha1.RedisBackup{}
+			Expect(k8sClient.Get(ctx, backupLookupKey, backup)).To(Succeed())
+			Expect(backup.Status.Phase).To(Equal(redisv1alpha1.BackupPhasePending))
+			condition := meta.FindStatusCondition(backup.Status.Conditions, conditionCompleted)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Reason).To(Equal("NoRunningPod"))
+		})
+
+		It("should render a Job fetching the snapshot and uploading it to S3", func() {
+			controllerReconciler := &RedisBackupReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			backup := &redisv1alpha1.RedisBackup{}
+			Expect(k8sClient.Get(ctx, backupLookupKey, backup)).To(Succeed())
+			Expect(backup.Spec.Destination.S3.Image).To(Equal("minio/mc"))
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: redisName + "-0", Namespace: resourceNamespace},
+				Status:     corev1.PodStatus{PodIP: "10.0.0.1"},
+			}
+
+			job, err := controllerReconciler.backupJob(backup, redis, pod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.OwnerReferences).To(HaveLen(1))
+			Expect(backupLocation(backup)).To(Equal("s3://redis-backups/nightly/test-backup-redis/test-backup.rdb"))
+
+			podSpec := job.Spec.Template.Spec
+			Expect(podSpec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+			Expect(podSpec.InitContainers).To(HaveLen(1))
+			dump := podSpec.InitContainers[0]
+			Expect(dump.Name).To(Equal(backupDumpContainer))
+			Expect(dump.Command[2]).To(ContainSubstring("--rdb /backup/test-backup-redis/test-backup.rdb"))
+			Expect(dump.Env).To(ContainElement(corev1.EnvVar{Name: "REDIS_HOST", Value: "10.0.0.1"}))
+
+			upload := podSpec.Containers[0]
+			Expect(upload.Image).To(Equal("minio/mc"))
+			Expect(upload.Env).To(ContainElement(corev1.EnvVar{Name: "S3_KEY", Value: "nightly/test-backup-redis/test-backup.rdb"}))
+			Expect(upload.Env).To(ContainElement(HaveField("Name", redisv1alpha1.BackupSecretAccessKeyKey)))
+
+			By("Writing to a claim instead")
+			backup.Spec.Destination = redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups", Path: "redis"}}
+			job, err = controllerReconciler.backupJob(backup, redis, pod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+			Expect(job.Spec.Template.Spec.Containers[0].Name).To(Equal(backupDumpContainer))
+			Expect(job.Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+			Expect(backupLocation(backup)).To(Equal("pvc://backups/redis/test-backup-redis/test-backup.rdb"))
+		})
+
+		It("should render a Job deleting the stored snapshot", func() {
+			controllerReconciler := &RedisBackupReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			backup := &redisv1alpha1.RedisBackup{}
+			Expect(k8sClient.Get(ctx, backupLookupKey, backup)).To(Succeed())
+			Expect(backup.Spec.DeletionPolicy).To(Equal(redisv1alpha1.BackupDeletionPolicyRetain))
+
+			job, err := controllerReconciler.deleteSnapshotJob(backup)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Name).To(Equal("test-backup-delete"))
+			container := job.Spec.Template.Spec.Containers[0]
+			Expect(container.Command[2]).To(ContainSubstring(`mc rm "backup/$S3_BUCKET/$S3_KEY"`))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "S3_KEY", Value: "nightly/test-backup-redis/test-backup.rdb"}))
+
+			backup.Spec.Destination = redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups", Path: "redis"}}
+			job, err = controllerReconciler.deleteSnapshotJob(backup)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Spec.Template.Spec.Containers[0].Command).To(Equal([]string{"rm", "-f", "/backup/redis/test-backup-redis/test-backup.rdb"}))
+
+			By("Skipping backups that never stored a snapshot")
+			deleted, err := controllerReconciler.deleteSnapshot(ctx, backup)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(BeTrue())
+		})
+
+		It("should reject a destination with both S3 and a claim", func() {
+			backup := &redisv1alpha1.RedisBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName + "-invalid", Namespace: resourceNamespace},
+				Spec: redisv1alpha1.RedisBackupSpec{
+					RedisName: redisName,
+					Destination: redisv1alpha1.BackupDestination{
+						S3:  &redisv1alpha1.S3Destination{Endpoint: "http://minio:9000", Bucket: "b", CredentialsSecretName: "c"},
+						PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, backup)).NotTo(Succeed())
+		})
+	})
+})
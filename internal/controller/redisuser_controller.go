@@ -0,0 +1,390 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// RedisUserReconciler reconciles a RedisUser object
+type RedisUserReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+const (
+	// conditionReady is the condition type set on the RedisUser status once the user is set on every pod.
+	conditionReady = "Ready"
+	// defaultACLUser is the ACL user authenticated with the password of the Redis instance.
+	defaultACLUser = "default"
+)
+
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=redis.yazio.com,resources=redisusers/finalizers,verbs=update
+
+// Reconcile sets the ACL user declared by a RedisUser on every running pod of its Redis instance and keeps
+// the credentials secret of the user up to date. ACL users are not persisted by Redis, so restarted pods are
+// picked up through the pod watch.
+func (r *RedisUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := logf.FromContext(ctx)
+
+	user := &redisv1alpha1.RedisUser{}
+	if err := r.Get(ctx, req.NamespacedName, user); err != nil {
+		if errors.IsNotFound(err) {
+			return reconciled()
+		}
+		log.Error(err, "unable to fetch RedisUser")
+		return requeueInstanceWithError(ctx, req.Name, req.Namespace, err)
+	}
+
+	if !user.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(user, FinalizerName) {
+			if err := r.deleteACLUser(ctx, user, aclUsername(user)); err != nil {
+				return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+			}
+			if previous := user.Status.Username; previous != "" && previous != aclUsername(user) {
+				if err := r.deleteACLUser(ctx, user, previous); err != nil {
+					return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+				}
+			}
+
+			controllerutil.RemoveFinalizer(user, FinalizerName)
+			if err := r.Update(ctx, user); err != nil {
+				return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+			}
+			log.Info("Finalizer removed from RedisUser", "name", user.Name)
+		}
+		return reconciled()
+	}
+
+	if aclUsername(user) == defaultACLUser {
+		// Setting the user would reset the password the operator, the replicas and the exporter use.
+		r.Recorder.Event(user, corev1.EventTypeWarning, "InvalidUsername", "The default user is managed by the Redis instance")
+		if err := r.setUserCondition(ctx, user, metav1.ConditionFalse, "InvalidUsername",
+			"The default user is managed by the Redis instance, set spec.username"); err != nil {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+		return reconciled()
+	}
+
+	if !controllerutil.ContainsFinalizer(user, FinalizerName) {
+		controllerutil.AddFinalizer(user, FinalizerName)
+		if err := r.Update(ctx, user); err != nil {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+	}
+
+	redis := &redisv1alpha1.Redis{}
+	if err := r.Get(ctx, types.NamespacedName{Name: user.Spec.RedisName, Namespace: user.Namespace}, redis); err != nil {
+		if !errors.IsNotFound(err) {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+		// The Redis watch picks the user up again once the instance is created.
+		if err := r.setUserCondition(ctx, user, metav1.ConditionFalse, "RedisNotFound",
+			fmt.Sprintf("Redis %s does not exist", user.Spec.RedisName)); err != nil {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+		return reconciled()
+	}
+
+	// Changing spec.username creates a new ACL user, so the one set before is removed.
+	if previous := user.Status.Username; previous != "" && previous != aclUsername(user) {
+		if err := r.deleteACLUser(ctx, user, previous); err != nil {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+	}
+
+	password, err := r.userPassword(ctx, user)
+	if err != nil {
+		return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+	}
+	if err := r.reconcileCredentials(ctx, user, redis, password); err != nil {
+		return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+	}
+
+	applied, total, err := r.setACLUser(ctx, user, redis, password)
+	if err != nil {
+		return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+	}
+	if applied < total {
+		if err := r.setUserCondition(ctx, user, metav1.ConditionFalse, "Applying",
+			fmt.Sprintf("ACL user %s is set on %d of %d running pods", aclUsername(user), applied, total)); err != nil {
+			return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+		}
+		return ctrl.Result{RequeueAfter: RequeueDelay}, nil
+	}
+
+	if !meta.IsStatusConditionTrue(user.Status.Conditions, conditionReady) {
+		r.Recorder.Event(user, corev1.EventTypeNormal, "UserApplied", fmt.Sprintf("Set ACL user %s on Redis %s", aclUsername(user), redis.Name))
+	}
+	if err := r.setUserCondition(ctx, user, metav1.ConditionTrue, "Applied",
+		fmt.Sprintf("ACL user %s is set on %d running pods", aclUsername(user), total)); err != nil {
+		return requeueInstanceWithError(ctx, user.Name, user.Namespace, err)
+	}
+	return reconciled()
+}
+
+// setACLUser runs ACL SETUSER for the user on every running pod of the Redis instance. It returns the
+// number of pods the user was set on and the number of running pods.
+func (r *RedisUserReconciler) setACLUser(ctx context.Context, user *redisv1alpha1.RedisUser, redis *redisv1alpha1.Redis, password string) (int, int, error) {
+	tlsConfig, err := readClientTLSConfig(ctx, r.Client, redis)
+	if err != nil {
+		return 0, 0, err
+	}
+	pods, err := listRedisPods(ctx, r.Client, redis)
+	if err != nil {
+		return 0, 0, err
+	}
+	adminPassword, err := readRedisPassword(ctx, r.Client, redis)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	args := append([]interface{}{"ACL", "SETUSER", aclUsername(user)}, aclRules(user, password)...)
+	applied, total := 0, 0
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		total++
+		rdb := redisClientForPod(redis, pod, adminPassword, tlsConfig)
+		err := rdb.Do(ctx, args...).Err()
+		_ = rdb.Close()
+		if err != nil {
+			logf.FromContext(ctx).Error(err, "Unable to set ACL user", "Pod.Name", pod.Name)
+			continue
+		}
+		applied++
+	}
+	return applied, total, nil
+}
+
+// deleteACLUser runs ACL DELUSER for the named ACL user of the RedisUser on every running pod of the Redis
+// instance. Nothing needs to be removed once the instance itself is gone or being deleted, and the default
+// user is never removed.
+func (r *RedisUserReconciler) deleteACLUser(ctx context.Context, user *redisv1alpha1.RedisUser, username string) error {
+	if username == defaultACLUser {
+		return nil
+	}
+	redis := &redisv1alpha1.Redis{}
+	if err := r.Get(ctx, types.NamespacedName{Name: user.Spec.RedisName, Namespace: user.Namespace}, redis); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !redis.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	tlsConfig, err := readClientTLSConfig(ctx, r.Client, redis)
+	if err != nil {
+		return err
+	}
+
+	pods, err := listRedisPods(ctx, r.Client, redis)
+	if err != nil {
+		return err
+	}
+	adminPassword, err := readRedisPassword(ctx, r.Client, redis)
+	if err != nil {
+		return err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		rdb := redisClientForPod(redis, pod, adminPassword, tlsConfig)
+		err := rdb.Do(ctx, "ACL", "DELUSER", username).Err()
+		_ = rdb.Close()
+		if err != nil {
+			return fmt.Errorf("deleting ACL user from pod %s: %w", pod.Name, err)
+		}
+	}
+	r.Recorder.Event(user, corev1.EventTypeNormal, "UserDeleted", fmt.Sprintf("Deleted ACL user %s from Redis %s", username, redis.Name))
+	return nil
+}
+
+// userPassword returns the password of the user, read from spec.passwordSecretName if set. Otherwise the
+// password generated earlier is kept from the credentials secret, or a new one is generated.
+func (r *RedisUserReconciler) userPassword(ctx context.Context, user *redisv1alpha1.RedisUser) (string, error) {
+	name := user.Spec.PasswordSecretName
+	if name == "" {
+		name = credentialsSecretName(user)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: user.Namespace}, secret); err != nil {
+		if !errors.IsNotFound(err) || user.Spec.PasswordSecretName != "" {
+			return "", err
+		}
+		return generateRandomPassword(16)
+	}
+	password, ok := secret.Data["password"]
+	if !ok {
+		if user.Spec.PasswordSecretName == "" {
+			return generateRandomPassword(16)
+		}
+		return "", fmt.Errorf("secret %s has no password key", secret.Name)
+	}
+	return string(password), nil
+}
+
+// reconcileCredentials ensures the credentials secret of the user holds its username, password and the
+// endpoint of the Redis instance, and records the secret and the ACL user in the status.
+func (r *RedisUserReconciler) reconcileCredentials(ctx context.Context, user *redisv1alpha1.RedisUser, redis *redisv1alpha1.Redis, password string) error {
+	desired := map[string][]byte{
+		"username": []byte(aclUsername(user)),
+		"password": []byte(password),
+		"endpoint": []byte(serviceEndpoint(redis.Spec.Service.Name, redis.Namespace, *redis.Spec.Service.Port)),
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: credentialsSecretName(user), Namespace: user.Namespace}, secret)
+	switch {
+	case errors.IsNotFound(err):
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: credentialsSecretName(user), Namespace: user.Namespace},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       desired,
+		}
+		if err := ctrl.SetControllerReference(user, secret, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return err
+		}
+		r.Recorder.Event(user, corev1.EventTypeNormal, "CreatedSecret", fmt.Sprintf("Created secret %s", secret.Name))
+	case err != nil:
+		return err
+	case !maps.EqualFunc(secret.Data, desired, func(a, b []byte) bool { return string(a) == string(b) }):
+		patch := client.MergeFrom(secret.DeepCopy())
+		secret.Data = desired
+		if err := r.Patch(ctx, secret, patch); err != nil {
+			return err
+		}
+		r.Recorder.Event(user, corev1.EventTypeNormal, "UpdatedSecret", fmt.Sprintf("Updated secret %s", secret.Name))
+	}
+
+	if user.Status.CredentialsSecretName == secret.Name && user.Status.Username == aclUsername(user) {
+		return nil
+	}
+	base := user.DeepCopy()
+	user.Status.CredentialsSecretName = secret.Name
+	user.Status.Username = aclUsername(user)
+	return r.Status().Patch(ctx, user, client.MergeFrom(base))
+}
+
+// setUserCondition patches the Ready condition on the RedisUser if it changed.
+func (r *RedisUserReconciler) setUserCondition(ctx context.Context, user *redisv1alpha1.RedisUser, status metav1.ConditionStatus, reason string, message string) error {
+	base := user.DeepCopy()
+	if !meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+		Type:               conditionReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: user.Generation,
+	}) {
+		return nil
+	}
+	return r.Status().Patch(ctx, user, client.MergeFrom(base))
+}
+
+// aclRules returns the ACL SETUSER rules of the user. The user is reset first, so rules removed from the
+// spec are revoked.
+func aclRules(user *redisv1alpha1.RedisUser, password string) []interface{} {
+	rules := []interface{}{"reset", "on", ">" + password}
+	for _, key := range user.Spec.Keys {
+		rules = append(rules, "~"+key)
+	}
+	for _, channel := range user.Spec.Channels {
+		rules = append(rules, "&"+channel)
+	}
+	for _, command := range user.Spec.Commands {
+		rules = append(rules, command)
+	}
+	return rules
+}
+
+// aclUsername returns the name of the ACL user declared by the RedisUser.
+func aclUsername(user *redisv1alpha1.RedisUser) string {
+	if user.Spec.Username != "" {
+		return user.Spec.Username
+	}
+	return user.Name
+}
+
+// credentialsSecretName returns the name of the secret holding the credentials of the user.
+func credentialsSecretName(user *redisv1alpha1.RedisUser) string {
+	return fmt.Sprintf("%s-credentials", user.Name)
+}
+
+// usersForObject maps a Redis instance, one of its pods or a password secret to the RedisUsers depending on it.
+func (r *RedisUserReconciler) usersForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	userList := &redisv1alpha1.RedisUserList{}
+	if err := r.List(ctx, userList, client.InNamespace(obj.GetNamespace())); err != nil {
+		logf.FromContext(ctx).Error(err, "Unable to list RedisUsers")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, user := range userList.Items {
+		var matches bool
+		switch obj.(type) {
+		case *redisv1alpha1.Redis:
+			matches = user.Spec.RedisName == obj.GetName()
+		case *corev1.Pod:
+			matches = user.Spec.RedisName == obj.GetLabels()["redis_cr"]
+		case *corev1.Secret:
+			matches = user.Spec.PasswordSecretName == obj.GetName()
+		}
+		if matches {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&user)})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RedisUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&redisv1alpha1.RedisUser{}).
+		Owns(&corev1.Secret{}).
+		Watches(&redisv1alpha1.Redis{}, handler.EnqueueRequestsFromMapFunc(r.usersForObject)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.usersForObject)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.usersForObject)).
+		Named("redisuser").
+		Complete(r)
+}
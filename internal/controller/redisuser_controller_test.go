@@ -0,0 +1,144 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+var _ = Describe("RedisUser Controller", func() {
+	Context("When reconciling a resource", func() {
+		const (
+			redisName         = "test-acl"
+			resourceName      = "test-acl-user"
+			resourceNamespace = "default"
+		)
+
+		ctx := context.Background()
+		redisLookupKey := types.NamespacedName{Name: redisName, Namespace: resourceNamespace}
+		userLookupKey := types.NamespacedName{Name: resourceName, Namespace: resourceNamespace}
+
+		BeforeEach(func() {
+			By("creating the Redis the user is declared on")
+			Expect(k8sClient.Create(ctx, newTestRedis(redisName, resourceNamespace))).To(Succeed())
+
+			By("creating the custom resource for the Kind RedisUser")
+			user := &redisv1alpha1.RedisUser{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: resourceNamespace},
+				Spec: redisv1alpha1.RedisUserSpec{
+					RedisName: redisName,
+					Username:  "app",
+					Commands:  []string{"+@read", "-flushall"},
+					Keys:      []string{"app:*"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, user)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			redis := &redisv1alpha1.Redis{}
+			Expect(k8sClient.Get(ctx, redisLookupKey, redis)).To(Succeed())
+
+			By("Cleanup the Redis instance")
+			Expect(k8sClient.Delete(ctx, redis)).To(Succeed())
+			// Drop the finalizer, so the next spec can create the instance again.
+			redisReconciler := &RedisReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Recorder: &record.FakeRecorder{}}
+			_, err := redisReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should write the credentials and remove the finalizer on deletion", func() {
+			redisReconciler := &RedisReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			_, err := redisReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: redisLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			controllerReconciler := &RedisUserReconciler{
+				Client:   k8sClient,
+				Scheme:   k8sClient.Scheme(),
+				Recorder: &record.FakeRecorder{},
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: userLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			user := &redisv1alpha1.RedisUser{}
+			Expect(k8sClient.Get(ctx, userLookupKey, user)).To(Succeed())
+			Expect(controllerutil.ContainsFinalizer(user, FinalizerName)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(user.Status.Conditions, conditionReady)).To(BeTrue())
+			Expect(user.Status.CredentialsSecretName).To(Equal(resourceName + "-credentials"))
+			Expect(user.Status.Username).To(Equal("app"))
+
+			By("Checking the credentials secret")
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: user.Status.CredentialsSecretName, Namespace: resourceNamespace}, secret)).To(Succeed())
+			Expect(string(secret.Data["username"])).To(Equal("app"))
+			Expect(string(secret.Data["endpoint"])).To(Equal("test-acl-service.default.svc:6379"))
+			password := string(secret.Data["password"])
+			Expect(password).To(HaveLen(16))
+
+			By("Keeping the generated password")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: userLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: user.Status.CredentialsSecretName, Namespace: resourceNamespace}, secret)).To(Succeed())
+			Expect(string(secret.Data["password"])).To(Equal(password))
+			Expect(aclRules(user, password)).To(Equal([]interface{}{"reset", "on", ">" + password, "~app:*", "+@read", "-flushall"}))
+
+			By("Deleting the RedisUser")
+			Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: userLookupKey})
+			Expect(err).NotTo(HaveOccurred())
+			err = k8sClient.Get(ctx, userLookupKey, user)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should reject the default user", func() {
+			user := &redisv1alpha1.RedisUser{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName + "-default", Namespace: resourceNamespace},
+				Spec:       redisv1alpha1.RedisUserSpec{RedisName: redisName, Username: "default"},
+			}
+			Expect(k8sClient.Create(ctx, user)).NotTo(Succeed())
+
+			By("Rejecting a RedisUser named default without a username")
+			user = &redisv1alpha1.RedisUser{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: resourceNamespace},
+				Spec:       redisv1alpha1.RedisUserSpec{RedisName: redisName},
+			}
+			Expect(k8sClient.Create(ctx, user)).NotTo(Succeed())
+
+			By("Cleanup the RedisUser")
+			user = &redisv1alpha1.RedisUser{}
+			Expect(k8sClient.Get(ctx, userLookupKey, user)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, user)).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,276 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// reconcileReplication elects a primary and configures every other Redis pod to replicate from it.
+// When Sentinel monitors the instance, its view of the primary takes precedence so that pod role labels
+// and status follow a failover. It reports whether every pod is running with its expected role, so the
+// caller can requeue otherwise.
+func (r *RedisReconciler) reconcileReplication(ctx context.Context, redis *v1alpha1.Redis) (bool, error) {
+	logger := log.FromContext(ctx)
+	if !isReplicated(redis) {
+		return true, nil
+	}
+
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		return false, err
+	}
+
+	// The data sets are checked before any pod is (re)pointed, so no replica follows an empty primary.
+	infos := r.replicationInfos(ctx, redis, pods, password)
+	primary := electPrimary(redis, pods)
+	if replacement := r.replaceEmptyPrimary(redis, pods, primary, infos); replacement != nil {
+		primary = replacement
+	}
+	sentinelManaged := false
+	if hasSentinel(redis) {
+		sentinelPrimary, err := r.sentinelPrimary(ctx, redis, pods, password)
+		if err != nil {
+			return false, err
+		}
+		if sentinelPrimary != nil {
+			primary = sentinelPrimary
+			sentinelManaged = true
+		}
+	}
+	if primary == nil {
+		return false, nil
+	}
+
+	primaryEmpty := emptyPrimary(infos[primary.Name])
+	converged := true
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			converged = false
+			continue
+		}
+
+		role := roleReplica
+		if pod.Name == primary.Name {
+			role = rolePrimary
+		} else if primaryEmpty && mayHoldData(infos[pod.Name]) {
+			// Resyncing from the empty primary would wipe the replica, so it is left alone.
+			logger.Info("Not pointing a replica that may hold data at an empty primary", "Pod.Name", pod.Name, "Primary", primary.Name)
+			converged = false
+			continue
+		}
+		// Unreachable pods are retried on the next reconcile instead of failing the whole instance.
+		if err := r.configureRole(ctx, redis, pod, primary.Name, role, password, sentinelManaged); err != nil {
+			logger.Error(err, "Unable to configure replication role", "Pod.Name", pod.Name, "Role", role)
+			converged = false
+			continue
+		}
+		if err := r.setPodRole(ctx, pod, role); err != nil {
+			return false, err
+		}
+	}
+
+	if redis.Status.Primary != primary.Name {
+		previous := redis.Status.Primary
+		base := redis.DeepCopy()
+		redis.Status.Primary = primary.Name
+		if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+			return false, err
+		}
+		if sentinelManaged && previous != "" {
+			r.Recorder.Event(redis, corev1.EventTypeWarning, "SentinelFailover", fmt.Sprintf("Sentinel promoted pod %s to primary, replacing %s", primary.Name, previous))
+		} else {
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "PrimaryElected", fmt.Sprintf("Pod %s is the replication primary", primary.Name))
+		}
+	}
+
+	// Sentinel may fail over at any time without touching any watched object, so its view is polled.
+	return converged && !hasSentinel(redis), nil
+}
+
+// electPrimary returns the pod that should act as primary. The recorded primary is kept for as long as
+// its pod exists, even while it restarts, so that a restart never promotes a replica behind its back.
+// Otherwise the running pod with the lowest ordinal is elected.
+func electPrimary(redis *v1alpha1.Redis, pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		if pods[i].Name == redis.Status.Primary {
+			return &pods[i]
+		}
+	}
+	for i := range pods {
+		if podRunning(&pods[i]) {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+// replicationInfos reads the replication and keyspace INFO sections of every running pod, keyed by pod name.
+// Pods that cannot be read are left out.
+func (r *RedisReconciler) replicationInfos(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, password string) map[string]map[string]string {
+	logger := log.FromContext(ctx)
+
+	infos := map[string]map[string]string{}
+	for i := range pods {
+		pod := &pods[i]
+		if !podRunning(pod) {
+			continue
+		}
+		rdb := redisClientForPod(redis, pod, password, r.clientTLSConfig(redis))
+		info, err := redisInfo(ctx, rdb, "replication")
+		if err == nil {
+			var keyspace map[string]string
+			keyspace, err = redisInfo(ctx, rdb, "keyspace")
+			maps.Copy(info, keyspace)
+		}
+		_ = rdb.Close()
+		if err != nil {
+			logger.Error(err, "Unable to read the replication offset", "Pod.Name", pod.Name)
+			continue
+		}
+		infos[pod.Name] = info
+	}
+	return infos
+}
+
+// replaceEmptyPrimary returns the replica to promote in place of the recorded primary if the primary lost its
+// data set, or nil. A primary restarted without persistence, or before it saved its data, comes back empty,
+// and keeping it would make the replicas resync from it and wipe their copy of the data.
+func (r *RedisReconciler) replaceEmptyPrimary(redis *v1alpha1.Redis, pods []corev1.Pod, primary *corev1.Pod, infos map[string]map[string]string) *corev1.Pod {
+	if primary == nil || primary.Name != redis.Status.Primary || !podRunning(primary) {
+		return nil
+	}
+
+	name := replicaAheadOfEmptyPrimary(primary.Name, infos)
+	for i := range pods {
+		if pods[i].Name == name {
+			r.Recorder.Event(redis, corev1.EventTypeWarning, "PrimaryDataLost", fmt.Sprintf("Pod %s restarted without its data, promoting replica %s instead", primary.Name, name))
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+// replicaAheadOfEmptyPrimary returns the name of the replica with the highest replication offset if the
+// primary is empty, or an empty string if the primary is to be kept.
+func replicaAheadOfEmptyPrimary(primary string, infos map[string]map[string]string) string {
+	if !emptyPrimary(infos[primary]) {
+		return ""
+	}
+	best, bestOffset := "", int64(0)
+	for name, info := range infos {
+		if name == primary || info["role"] != "slave" {
+			continue
+		}
+		offset, err := strconv.ParseInt(info["master_repl_offset"], 10, 64)
+		if err == nil && (offset > bestOffset || (offset == bestOffset && best != "" && name < best)) {
+			best, bestOffset = name, offset
+		}
+	}
+	return best
+}
+
+// emptyPrimary reports whether the INFO of a pod shows a primary with an empty replication stream and no keys,
+// as a primary restarted without its data does.
+func emptyPrimary(info map[string]string) bool {
+	return info != nil && info["role"] == "master" && info["master_repl_offset"] == "0" && !hasKeys(info)
+}
+
+// mayHoldData reports whether the INFO of a pod, if it could be read at all, shows replicated data or keys.
+func mayHoldData(info map[string]string) bool {
+	return info == nil || (info["master_repl_offset"] != "" && info["master_repl_offset"] != "0") || hasKeys(info)
+}
+
+// hasKeys reports whether the keyspace section of an INFO reply lists a database holding keys.
+func hasKeys(info map[string]string) bool {
+	for key := range info {
+		if strings.HasPrefix(key, "db") {
+			return true
+		}
+	}
+	return false
+}
+
+// configureRole makes the Redis server in the pod a primary or a replica of the given primary pod.
+// Once Sentinel manages the topology, only freshly started pods, which come up as empty primaries,
+// are pointed at the primary; everything else is left to Sentinel.
+func (r *RedisReconciler) configureRole(ctx context.Context, redis *v1alpha1.Redis, pod *corev1.Pod, primaryName string, role string, password string, sentinelManaged bool) error {
+	rdb := redisClientForPod(redis, pod, password, r.clientTLSConfig(redis))
+	defer func() { _ = rdb.Close() }()
+
+	info, err := redisInfo(ctx, rdb, "replication")
+	if err != nil {
+		return err
+	}
+
+	if sentinelManaged && (role == rolePrimary || info["role"] != "master" || info["master_repl_offset"] != "0") {
+		return nil
+	}
+
+	if role == rolePrimary {
+		if info["role"] == "master" {
+			return nil
+		}
+		if err := rdb.Do(ctx, "REPLICAOF", "NO", "ONE").Err(); err != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "PromotedPrimary", fmt.Sprintf("Promoted pod %s to primary", pod.Name))
+		return nil
+	}
+
+	primaryHost := podHost(redis, primaryName)
+	primaryPort := strconv.Itoa(int(*redis.Spec.Port))
+	if info["role"] == "slave" && info["master_host"] == primaryHost && info["master_port"] == primaryPort {
+		return nil
+	}
+	if err := rdb.ConfigSet(ctx, "masterauth", password).Err(); err != nil {
+		return err
+	}
+	if err := rdb.Do(ctx, "REPLICAOF", primaryHost, primaryPort).Err(); err != nil {
+		return err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "ConfiguredReplica", fmt.Sprintf("Pod %s now replicates from %s", pod.Name, primaryName))
+	return nil
+}
+
+// setPodRole labels a pod with its replication role so that services can select it.
+func (r *RedisReconciler) setPodRole(ctx context.Context, pod *corev1.Pod, role string) error {
+	if pod.Labels[roleLabel] == role {
+		return nil
+	}
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[roleLabel] = role
+	return r.Patch(ctx, pod, patch)
+}
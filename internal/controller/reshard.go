@@ -0,0 +1,261 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// reshardBatchSlots bounds the number of hash slots migrated in a single reconcile.
+	reshardBatchSlots = 64
+	// migrateBatchKeys bounds the number of keys moved by a single MIGRATE command.
+	migrateBatchKeys = 100
+	// migrateTimeoutMillis bounds how long a single MIGRATE command may take.
+	migrateTimeoutMillis = 5000
+)
+
+// slotMove moves a single hash slot from one shard to another.
+type slotMove struct {
+	slot int
+	from int
+	to   int
+}
+
+// reconcileResharding spreads the hash slots evenly across the first spec.cluster.shards shards and drains
+// every shard beyond them. Slots are migrated in bounded batches, one batch per reconcile. The slot ownership
+// recorded by the cluster itself is the source of truth, so an interrupted migration is completed first, a
+// destination left importing a slot its source no longer migrates is reset, and the work resumes where it
+// stopped after an operator restart.
+// It reports whether slots still need to be moved.
+func (r *RedisReconciler) reconcileResharding(ctx context.Context, redis *v1alpha1.Redis, members []*clusterMember, password string) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	byID := map[string]*clusterMember{}
+	for _, member := range members {
+		byID[member.id()] = member
+	}
+
+	// Finish migrations interrupted by a failed reconcile or an operator restart before planning new ones.
+	resumed := false
+	for _, member := range members {
+		self := member.self()
+		if self == nil {
+			continue
+		}
+		for slot, destinationID := range self.Migrating {
+			destination, ok := byID[destinationID]
+			if !ok {
+				return false, fmt.Errorf("slot %d is migrating to unknown node %s", slot, destinationID)
+			}
+			if err := migrateSlot(ctx, redis, members, member, destination, slot, password); err != nil {
+				return false, err
+			}
+			logger.Info("Completed interrupted slot migration", "Slot", slot, "From", member.pod.Name, "To", destination.pod.Name)
+			resumed = true
+		}
+	}
+	// A migration interrupted before the source was marked migrating leaves only the destination importing.
+	for _, member := range members {
+		self := member.self()
+		if self == nil {
+			continue
+		}
+		for slot, sourceID := range self.Importing {
+			if source, ok := byID[sourceID]; ok && source.self() != nil && source.self().Migrating[slot] == self.ID {
+				continue
+			}
+			if err := member.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "STABLE").Err(); err != nil {
+				return false, err
+			}
+			logger.Info("Cleared stale slot import", "Slot", slot, "Pod.Name", member.pod.Name)
+			resumed = true
+		}
+	}
+	if resumed {
+		return true, nil
+	}
+
+	shards := clusterShards(redis, members)
+	primaries := make([]*clusterMember, len(shards))
+	owned := make([][]int, len(shards))
+	for i, shard := range shards {
+		primaries[i] = shardPrimary(shard)
+		if primaries[i] == nil {
+			// A shard without a primary can neither give nor take slots until its pods are wired up.
+			return true, nil
+		}
+		owned[i] = expandSlots(primaries[i].self().Slots)
+	}
+
+	desiredShards := int(redis.Spec.Cluster.Shards)
+	moves, remaining := planSlotMoves(owned, slotTargets(desiredShards, len(shards)), reshardBatchSlots)
+	if remaining == 0 {
+		if meta.FindStatusCondition(redis.Status.Conditions, conditionResharding) == nil {
+			return false, nil
+		}
+		return false, r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionResharding,
+			Status:  metav1.ConditionFalse,
+			Reason:  "ReshardComplete",
+			Message: fmt.Sprintf("Hash slots are spread across %d shards", desiredShards),
+		})
+	}
+
+	if !meta.IsStatusConditionTrue(redis.Status.Conditions, conditionResharding) {
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "ReshardingStarted", fmt.Sprintf("Moving %d hash slots to spread them across %d shards", remaining, desiredShards))
+	}
+	if err := r.setCondition(ctx, redis, metav1.Condition{
+		Type:    conditionResharding,
+		Status:  metav1.ConditionTrue,
+		Reason:  "MigratingSlots",
+		Message: fmt.Sprintf("%d hash slots left to move to spread them across %d shards", remaining, desiredShards),
+	}); err != nil {
+		return false, err
+	}
+
+	for _, move := range moves {
+		if err := migrateSlot(ctx, redis, members, primaries[move.from], primaries[move.to], move.slot, password); err != nil {
+			return false, err
+		}
+	}
+	logger.Info("Migrated hash slots", "Slots", len(moves), "Remaining", remaining-len(moves))
+	return true, nil
+}
+
+// migrateSlot moves a hash slot and its keys from the source to the destination primary,
+// following the same steps as redis-cli --cluster reshard.
+func migrateSlot(ctx context.Context, redis *v1alpha1.Redis, members []*clusterMember, source *clusterMember, destination *clusterMember, slot int, password string) error {
+	sourceID, destinationID := source.id(), destination.id()
+	if err := destination.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "IMPORTING", sourceID).Err(); err != nil {
+		return err
+	}
+	if err := source.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "MIGRATING", destinationID).Err(); err != nil {
+		return err
+	}
+
+	for {
+		keys, err := source.rdb.ClusterGetKeysInSlot(ctx, slot, migrateBatchKeys).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			break
+		}
+		args := []interface{}{"MIGRATE", destination.pod.Status.PodIP, *redis.Spec.Port, "", 0, migrateTimeoutMillis, "AUTH", password, "KEYS"}
+		for _, key := range keys {
+			args = append(args, key)
+		}
+		if err := source.rdb.Do(ctx, args...).Err(); err != nil {
+			return err
+		}
+	}
+
+	// The destination takes ownership first, so clients are never redirected to a node that rejects the slot.
+	if err := destination.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "NODE", destinationID).Err(); err != nil {
+		return err
+	}
+	if err := source.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "NODE", destinationID).Err(); err != nil {
+		return err
+	}
+	// The other primaries learn the new owner right away instead of waiting for it to spread by gossip.
+	for _, member := range members {
+		if member == source || member == destination || member.self() == nil || !member.self().Primary {
+			continue
+		}
+		if err := member.rdb.Do(ctx, "CLUSTER", "SETSLOT", slot, "NODE", destinationID).Err(); err != nil {
+			return fmt.Errorf("announcing the owner of slot %d to pod %s: %w", slot, member.pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// slotTargets returns the number of hash slots every shard should serve. The first desired shards
+// share the slots evenly and every shard beyond them is drained.
+func slotTargets(desiredShards int, shards int) []int {
+	targets := make([]int, shards)
+	for i := 0; i < desiredShards && i < shards; i++ {
+		targets[i] = clusterSlots / desiredShards
+		if i < clusterSlots%desiredShards {
+			targets[i]++
+		}
+	}
+	return targets
+}
+
+// planSlotMoves plans at most limit slot moves from shards serving more slots than their target to shards
+// serving fewer. It also returns the number of slots that need to move in total.
+func planSlotMoves(owned [][]int, targets []int, limit int) ([]slotMove, int) {
+	var surplus []slotMove
+	for from, slots := range owned {
+		if excess := len(slots) - targets[from]; excess > 0 {
+			// Give away the highest slots, so every shard keeps a contiguous range where possible.
+			for _, slot := range slots[len(slots)-excess:] {
+				surplus = append(surplus, slotMove{slot: slot, from: from})
+			}
+		}
+	}
+
+	var moves []slotMove
+	next := 0
+	for to, slots := range owned {
+		for deficit := targets[to] - len(slots); deficit > 0 && next < len(surplus); deficit-- {
+			move := surplus[next]
+			move.to = to
+			moves = append(moves, move)
+			next++
+		}
+	}
+
+	remaining := len(moves)
+	if len(moves) > limit {
+		moves = moves[:limit]
+	}
+	return moves, remaining
+}
+
+// expandSlots expands a list of slots and slot ranges into the individual slots.
+func expandSlots(slots []string) []int {
+	var expanded []int
+	for _, slot := range slots {
+		from, to, isRange := strings.Cut(slot, "-")
+		start, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(to); err != nil {
+				continue
+			}
+		}
+		for s := start; s <= end; s++ {
+			expanded = append(expanded, s)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// restoreFetchContainer is the name of the init container downloading the snapshot to restore.
+	restoreFetchContainer = "restore-fetch"
+	// restoreContainer is the name of the init container verifying the snapshot and moving it into the data
+	// directory. It reports the outcome in its termination message.
+	restoreContainer = "restore"
+	// restoreHTTPImage is the image downloading snapshots from http(s) URLs.
+	restoreHTTPImage = "curlimages/curl"
+	// restoreVolumeName is the name of the scratch volume the snapshot is downloaded to.
+	restoreVolumeName = "restore"
+	// restoreDir is the directory the scratch volume is mounted at.
+	restoreDir = "/restore"
+	// restoreSourceVolumeName is the name of the volume of the claim a RedisBackup stored its snapshot on.
+	restoreSourceVolumeName = "restore-source"
+	// restoreMarkerVolumeName is the name of the volume of the config map marking a finished restore.
+	restoreMarkerVolumeName = "restore-marker"
+	// restoreMarkerDir is the directory the marker config map is mounted at.
+	restoreMarkerDir = "/etc/redis-restore"
+	// restoredKey is the key of the marker config map the init containers check for.
+	restoredKey = "restored"
+	// restoredMessagePrefix starts the termination message of a restore container that restored the snapshot.
+	restoredMessagePrefix = "Restored"
+)
+
+// dataPresentCheck is a shell condition that holds if the data directory already holds a snapshot or an
+// append-only file, in which case the restore is skipped.
+var dataPresentCheck = fmt.Sprintf("[ -e %[1]s/dump.rdb ] || [ -e %[1]s/appendonlydir ] || [ -e %[1]s/appendonly.aof ]", redisDataDir)
+
+// restoredCheck is a shell condition that holds once the instance finished its restore, in which case pods
+// started later skip it and start empty or resync from the primary instead of restoring the snapshot again.
+var restoredCheck = fmt.Sprintf("[ -e %s ]", path.Join(restoreMarkerDir, restoredKey))
+
+// restoredConfigMapName returns the name of the config map marking that the Redis finished its restore.
+func restoredConfigMapName(redis *v1alpha1.Redis) string {
+	return redis.Name + "-restored"
+}
+
+// restoreSource is the resolved location of the snapshot a Redis is bootstrapped from. Exactly one of url,
+// s3 and claim is set.
+type restoreSource struct {
+	// description names the snapshot in the Restored condition.
+	description string
+	// url is an http(s) URL of the snapshot.
+	url string
+	// s3 is an S3 object holding the snapshot.
+	s3 *s3Object
+	// claim is the PersistentVolumeClaim holding the snapshot at claimPath.
+	claim     string
+	claimPath string
+	// checksum is the expected checksum of the snapshot, formatted as sha256:<hex>, if known.
+	checksum string
+}
+
+// s3Object is an object of an S3-compatible object store fetched with the MinIO client.
+type s3Object struct {
+	endpoint              string
+	bucket                string
+	key                   string
+	credentialsSecretName string
+	insecureSkipVerify    bool
+	image                 string
+}
+
+// resolveRestore returns the snapshot spec.restore bootstraps the Redis from, or nil without spec.restore.
+// A referenced RedisBackup has to be completed; until then the Restored condition reports why and nil is
+// returned, so the workload is held back.
+func (r *RedisReconciler) resolveRestore(ctx context.Context, redis *v1alpha1.Redis) (*restoreSource, error) {
+	spec := redis.Spec.Restore
+	if spec == nil {
+		marker := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: restoredConfigMapName(redis), Namespace: redis.Namespace}}
+		if err := r.deleteIfOwned(ctx, redis, marker); err != nil {
+			return nil, err
+		}
+		return nil, r.removeCondition(ctx, redis, conditionRestored)
+	}
+
+	if spec.BackupName == "" {
+		source := &restoreSource{description: spec.URL, checksum: spec.Checksum}
+		bucketKey, isS3 := strings.CutPrefix(spec.URL, "s3://")
+		if !isS3 {
+			source.url = spec.URL
+			return source, nil
+		}
+		bucket, key, _ := strings.Cut(bucketKey, "/")
+		if spec.S3 == nil || bucket == "" || key == "" {
+			return nil, r.setCondition(ctx, redis, metav1.Condition{
+				Type:    conditionRestored,
+				Status:  metav1.ConditionFalse,
+				Reason:  "InvalidSource",
+				Message: fmt.Sprintf("%s needs spec.restore.s3 and has to name a bucket and a key", spec.URL),
+			})
+		}
+		source.s3 = &s3Object{
+			endpoint:              spec.S3.Endpoint,
+			bucket:                bucket,
+			key:                   key,
+			credentialsSecretName: spec.S3.CredentialsSecretName,
+			insecureSkipVerify:    spec.S3.InsecureSkipVerify,
+			image:                 spec.S3.Image,
+		}
+		return source, nil
+	}
+
+	backup := &v1alpha1.RedisBackup{}
+	if err := r.Get(ctx, types.NamespacedName{Name: spec.BackupName, Namespace: redis.Namespace}, backup); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionRestored,
+			Status:  metav1.ConditionFalse,
+			Reason:  "BackupNotFound",
+			Message: fmt.Sprintf("RedisBackup %s does not exist", spec.BackupName),
+		})
+	}
+	if backup.Status.Phase != v1alpha1.BackupPhaseCompleted {
+		return nil, r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionRestored,
+			Status:  metav1.ConditionFalse,
+			Reason:  "BackupNotCompleted",
+			Message: fmt.Sprintf("RedisBackup %s has not completed", spec.BackupName),
+		})
+	}
+
+	source := &restoreSource{description: backup.Status.Location, checksum: backup.Status.Checksum}
+	if claim := backup.Spec.Destination.PVC; claim != nil {
+		source.claim = claim.ClaimName
+		source.claimPath = backupFilePath(backup)
+		return source, nil
+	}
+	s3 := backup.Spec.Destination.S3
+	source.s3 = &s3Object{
+		endpoint:              s3.Endpoint,
+		bucket:                s3.Bucket,
+		key:                   s3ObjectKey(backup),
+		credentialsSecretName: s3.CredentialsSecretName,
+		insecureSkipVerify:    s3.InsecureSkipVerify,
+		image:                 s3.Image,
+	}
+	return source, nil
+}
+
+// reportRestore sets the Restored condition from the termination messages of the restore init containers
+// and reports whether the restore finished. Once a pod restored the snapshot the condition stays true, even
+// if pods restarted later skip the restore because their data directory is no longer empty.
+func (r *RedisReconciler) reportRestore(ctx context.Context, redis *v1alpha1.Redis, source *restoreSource) (bool, error) {
+	if source == nil {
+		return true, nil
+	}
+	if meta.IsStatusConditionTrue(redis.Status.Conditions, conditionRestored) {
+		return true, nil
+	}
+	pods, err := listRedisPods(ctx, r.Client, redis)
+	if err != nil {
+		return false, err
+	}
+
+	condition := metav1.Condition{
+		Type:    conditionRestored,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Restoring",
+		Message: fmt.Sprintf("Waiting for the pods to restore %s", source.description),
+	}
+	var failed, skipped *metav1.Condition
+	for _, pod := range pods {
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name != restoreFetchContainer && status.Name != restoreContainer {
+				continue
+			}
+			terminated := status.State.Terminated
+			if terminated == nil {
+				// Init containers that keep failing are waiting for their next attempt.
+				terminated = status.LastTerminationState.Terminated
+			}
+			if terminated == nil {
+				continue
+			}
+			message := strings.TrimSpace(terminated.Message)
+			switch {
+			case terminated.ExitCode != 0:
+				failed = &metav1.Condition{Status: metav1.ConditionFalse, Reason: "RestoreFailed",
+					Message: fmt.Sprintf("Pod %s failed to restore %s: %s", pod.Name, source.description, message)}
+			case status.Name == restoreContainer && strings.HasPrefix(message, restoredMessagePrefix):
+				condition.Status, condition.Reason, condition.Message = metav1.ConditionTrue, "Restored", message
+				return true, r.setCondition(ctx, redis, condition)
+			case status.Name == restoreContainer:
+				skipped = &metav1.Condition{Status: metav1.ConditionFalse, Reason: "DataPresent", Message: message}
+			}
+		}
+	}
+
+	done := false
+	if failed != nil {
+		condition.Reason, condition.Message = failed.Reason, failed.Message
+	} else if skipped != nil {
+		// The pods started with data, so the snapshot will not be restored.
+		condition.Reason, condition.Message = skipped.Reason, skipped.Message
+		done = true
+	}
+	return done, r.setCondition(ctx, redis, condition)
+}
+
+// markRestored creates the config map telling the restore init containers that the instance finished its
+// restore. The init containers stay in the pod template, as dropping them would roll the pods and lose the
+// restored data of instances without persistence, but pods started later skip the restore.
+func (r *RedisReconciler) markRestored(ctx context.Context, redis *v1alpha1.Redis) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: restoredConfigMapName(redis), Namespace: redis.Namespace, Labels: labelsForRedis(redis.Name)},
+		Data:       map[string]string{restoredKey: "true"},
+	}
+	_ = ctrl.SetControllerReference(redis, cm, r.Scheme)
+	_, err := r.ensureConfigMap(ctx, redis, cm)
+	return err
+}
+
+// withRestore adds the init containers restoring the snapshot into the data directory to the pod template.
+// The first downloads the snapshot to a scratch volume, the second verifies its checksum and format with
+// redis-check-rdb and moves it to dump.rdb. Both leave a data directory that already holds data alone and
+// skip the restore once the marker config map created by markRestored exists.
+func withRestore(template corev1.PodTemplateSpec, redis *v1alpha1.Redis, source *restoreSource) corev1.PodTemplateSpec {
+	if source == nil {
+		return template
+	}
+	snapshot := path.Join(restoreDir, "dump.rdb")
+
+	fetch := corev1.Container{
+		Name:                     restoreFetchContainer,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: dataVolumeName, MountPath: redisDataDir, ReadOnly: true},
+			{Name: restoreVolumeName, MountPath: restoreDir},
+			{Name: restoreMarkerVolumeName, MountPath: restoreMarkerDir, ReadOnly: true},
+		},
+	}
+	var download string
+	switch {
+	case source.s3 != nil:
+		insecure := ""
+		if source.s3.insecureSkipVerify {
+			insecure = " --insecure"
+		}
+		fetch.Image = source.s3.image
+		fetch.Env = s3ClientEnv(source.s3.endpoint, source.s3.bucket, source.s3.key, source.s3.credentialsSecretName)
+		download = fmt.Sprintf(`mc%[1]s alias set restore "$S3_ENDPOINT" "$%[2]s" "$%[3]s"
+mc%[1]s cp "restore/$S3_BUCKET/$S3_KEY" %[4]s`, insecure, v1alpha1.BackupAccessKeyIDKey, v1alpha1.BackupSecretAccessKeyKey, snapshot)
+	case source.claim != "":
+		fetch.Image = v1alpha1.ImageWithTag(redis.Spec.Image)
+		fetch.VolumeMounts = append(fetch.VolumeMounts, corev1.VolumeMount{Name: restoreSourceVolumeName, MountPath: backupDir, ReadOnly: true})
+		download = fmt.Sprintf("cp %s %s", path.Join(backupDir, source.claimPath), snapshot)
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: restoreSourceVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: source.claim, ReadOnly: true},
+			},
+		})
+	default:
+		fetch.Image = restoreHTTPImage
+		fetch.Env = []corev1.EnvVar{{Name: "RESTORE_URL", Value: source.url}}
+		download = fmt.Sprintf(`curl -fsSL --retry 3 -o %s "$RESTORE_URL"`, snapshot)
+	}
+	fetch.Command = []string{"sh", "-c", fmt.Sprintf(`set -e
+if %s || %s; then
+  exit 0
+fi
+%s
+`, restoredCheck, dataPresentCheck, download)}
+
+	verify := corev1.Container{
+		Name:                     restoreContainer,
+		Image:                    v1alpha1.ImageWithTag(redis.Spec.Image),
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		Command: []string{"sh", "-c", fmt.Sprintf(`set -e
+if %[5]s; then
+  echo "Skipped the restore, the instance was already restored" > /dev/termination-log
+  exit 0
+fi
+if %[1]s; then
+  echo "Skipped the restore, %[2]s already holds data" > /dev/termination-log
+  exit 0
+fi
+if [ -n "$RESTORE_CHECKSUM" ] && [ "sha256:$(sha256sum %[3]s | cut -d ' ' -f 1)" != "$RESTORE_CHECKSUM" ]; then
+  echo "Checksum of $RESTORE_SOURCE does not match $RESTORE_CHECKSUM" > /dev/termination-log
+  exit 1
+fi
+if ! redis-check-rdb %[3]s > /dev/null; then
+  echo "$RESTORE_SOURCE is not a valid RDB file" > /dev/termination-log
+  exit 1
+fi
+cp %[3]s %[2]s/dump.rdb.restore
+mv %[2]s/dump.rdb.restore %[2]s/dump.rdb
+echo "%[4]s $(stat -c %%s %[2]s/dump.rdb) bytes from $RESTORE_SOURCE" > /dev/termination-log
+`, dataPresentCheck, redisDataDir, snapshot, restoredMessagePrefix, restoredCheck)},
+		Env: []corev1.EnvVar{
+			{Name: "RESTORE_SOURCE", Value: source.description},
+			{Name: "RESTORE_CHECKSUM", Value: source.checksum},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: dataVolumeName, MountPath: redisDataDir},
+			{Name: restoreVolumeName, MountPath: restoreDir, ReadOnly: true},
+			{Name: restoreMarkerVolumeName, MountPath: restoreMarkerDir, ReadOnly: true},
+		},
+	}
+
+	template.Spec.InitContainers = append(template.Spec.InitContainers, fetch, verify)
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name:         restoreVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}, corev1.Volume{
+		Name: restoreMarkerVolumeName,
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: restoredConfigMapName(redis)},
+			Optional:             ptr.To(true),
+		}},
+	})
+	return template
+}
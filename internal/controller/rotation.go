@@ -0,0 +1,316 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// rotatePasswordAnnotation requests a password rotation. It is removed once the rotation has started.
+	rotatePasswordAnnotation = "redis.yazio.com/rotate-password"
+
+	// nextPasswordKey holds the new password in the password secret until every pod accepts it.
+	nextPasswordKey = "next-password"
+	// previousPasswordKey holds the old password in the password secret during the grace period.
+	previousPasswordKey = "previous-password"
+
+	// authVolumeName is the name of the volume projecting the password secret into the pods.
+	authVolumeName = "auth"
+	// redisAuthDir is the directory the password secret is projected to. Unlike environment variables,
+	// the projected file follows secret updates, so probes keep authenticating after a rotation.
+	redisAuthDir = v1alpha1.PasswordMountPath
+)
+
+// passwordTarget is a running server that authenticates clients with the Redis password.
+type passwordTarget struct {
+	pod      *corev1.Pod
+	port     int32
+	sentinel bool
+}
+
+// reconcilePasswordRotation rotates the Redis password when spec.passwordRotation asks for it. The rotation
+// runs in three steps, each recorded in the password secret so that it resumes after an operator restart:
+//  1. a new password is generated and stored under next-password,
+//  2. once every pod accepts it next to the old one, it becomes the password and the old one is kept
+//     under previous-password,
+//  3. after the grace period the old password is dropped from every pod and from the secret.
+//
+// It returns the delay after which the rotation needs another reconcile, or zero.
+func (r *RedisReconciler) reconcilePasswordRotation(ctx context.Context, redis *v1alpha1.Redis) (time.Duration, error) {
+	if redis.Spec.PasswordRotation == nil {
+		return 0, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: redis.Spec.PasswordSecretName, Namespace: redis.Namespace}, secret); err != nil {
+		return 0, err
+	}
+	current := string(secret.Data[v1alpha1.PasswordSecretKey(redis)])
+	patch := client.MergeFrom(secret.DeepCopy())
+	if changed, err := setExporterPasswordFile(redis, secret, current); err != nil {
+		return 0, err
+	} else if changed {
+		if err := r.Patch(ctx, secret, patch); err != nil {
+			return 0, err
+		}
+	}
+
+	if previous, ok := secret.Data[previousPasswordKey]; ok {
+		return r.finishPasswordRotation(ctx, redis, secret, current, string(previous))
+	}
+	if next, ok := secret.Data[nextPasswordKey]; ok {
+		return r.promotePassword(ctx, redis, secret, current, string(next))
+	}
+
+	if due, wait := rotationDue(redis, secret, time.Now()); !due {
+		return wait, nil
+	}
+
+	next, err := generateRandomPassword(16)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate random password: %w", err)
+	}
+	patch = client.MergeFrom(secret.DeepCopy())
+	secret.Data[nextPasswordKey] = []byte(next)
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return 0, err
+	}
+	if _, ok := redis.Annotations[rotatePasswordAnnotation]; ok {
+		patch := client.MergeFrom(redis.DeepCopy())
+		delete(redis.Annotations, rotatePasswordAnnotation)
+		if err := r.Patch(ctx, redis, patch); err != nil {
+			return 0, err
+		}
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "PasswordRotationStarted", "Generated a new password")
+
+	return r.promotePassword(ctx, redis, secret, current, next)
+}
+
+// promotePassword makes every server accept the next password next to the current one and then makes it
+// the password in the secret.
+func (r *RedisReconciler) promotePassword(ctx context.Context, redis *v1alpha1.Redis, secret *corev1.Secret, current string, next string) (time.Duration, error) {
+	if !r.setPasswords(ctx, redis, []string{current, next}, next, current, next) {
+		return RequeueDelay, nil
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	secret.Data[v1alpha1.PasswordSecretKey(redis)] = []byte(next)
+	secret.Data[previousPasswordKey] = []byte(current)
+	delete(secret.Data, nextPasswordKey)
+	if _, err := setExporterPasswordFile(redis, secret, next); err != nil {
+		return 0, err
+	}
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return 0, err
+	}
+
+	base := redis.DeepCopy()
+	redis.Status.PasswordRotationStartTime = ptrTime(metav1.Now())
+	if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+		return 0, err
+	}
+	gracePeriod := redis.Spec.PasswordRotation.GracePeriod.Duration
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "PasswordUpdated", fmt.Sprintf("Updated secret %s, the old password is accepted for %s", secret.Name, gracePeriod))
+	return gracePeriod, nil
+}
+
+// finishPasswordRotation keeps the previous password accepted until the grace period has passed, covering
+// pods that restarted in the meantime, and then drops it.
+func (r *RedisReconciler) finishPasswordRotation(ctx context.Context, redis *v1alpha1.Redis, secret *corev1.Secret, current string, previous string) (time.Duration, error) {
+	started := redis.Status.PasswordRotationStartTime
+	if started == nil {
+		// The status was lost after the secret was updated, so the grace period starts over.
+		base := redis.DeepCopy()
+		redis.Status.PasswordRotationStartTime = ptrTime(metav1.Now())
+		if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+			return 0, err
+		}
+		started = redis.Status.PasswordRotationStartTime
+	}
+
+	if remaining := time.Until(started.Add(redis.Spec.PasswordRotation.GracePeriod.Duration)); remaining > 0 {
+		if !r.setPasswords(ctx, redis, []string{current, previous}, current, current, previous) {
+			return min(RequeueDelay, remaining), nil
+		}
+		return remaining, nil
+	}
+
+	// The exporters still authenticate with the previous password until they reload their password file.
+	if !r.reloadExporters(ctx, redis) || !r.setPasswords(ctx, redis, []string{current}, current, current, previous) {
+		return RequeueDelay, nil
+	}
+	patch := client.MergeFrom(secret.DeepCopy())
+	delete(secret.Data, previousPasswordKey)
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return 0, err
+	}
+
+	base := redis.DeepCopy()
+	redis.Status.LastPasswordRotationTime = ptrTime(metav1.Now())
+	redis.Status.PasswordRotationStartTime = nil
+	if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+		return 0, err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "PasswordRotated", "Dropped the old password")
+
+	_, wait := rotationDue(redis, secret, time.Now())
+	return wait, nil
+}
+
+// setPasswords makes every running Redis and Sentinel server accept exactly the given passwords and makes
+// replicas and Sentinels authenticate with the primary password. It connects with whichever of the known
+// passwords a server accepts, and reports whether every server was updated.
+func (r *RedisReconciler) setPasswords(ctx context.Context, redis *v1alpha1.Redis, passwords []string, primaryPassword string, known ...string) bool {
+	logger := log.FromContext(ctx)
+
+	targets, err := r.passwordTargets(ctx, redis)
+	if err != nil {
+		logger.Error(err, "Unable to list the pods to rotate the password on")
+		return false
+	}
+
+	args := []interface{}{"ACL", "SETUSER", "default", "resetpass"}
+	for _, password := range passwords {
+		args = append(args, ">"+password)
+	}
+
+	updated := true
+	for _, target := range targets {
+		err := func() error {
+			rdb, err := dialRedis(ctx, target.pod.Status.PodIP, target.port, r.clientTLSConfig(redis), known...)
+			if err != nil {
+				return err
+			}
+			defer func() { _ = rdb.Close() }()
+
+			if err := rdb.Do(ctx, args...).Err(); err != nil {
+				return err
+			}
+			if target.sentinel {
+				if err := rdb.Do(ctx, "SENTINEL", "SET", redis.Name, "auth-pass", primaryPassword).Err(); err != nil {
+					return err
+				}
+				return rdb.Do(ctx, "SENTINEL", "CONFIG", "SET", "sentinel-pass", primaryPassword).Err()
+			}
+			if isReplicated(redis) || isClustered(redis) {
+				return rdb.ConfigSet(ctx, "masterauth", primaryPassword).Err()
+			}
+			return nil
+		}()
+		if err != nil {
+			logger.Error(err, "Unable to set the passwords", "Pod.Name", target.pod.Name)
+			updated = false
+		}
+	}
+	return updated
+}
+
+// passwordTargets lists the running Redis and Sentinel pods of the instance.
+func (r *RedisReconciler) passwordTargets(ctx context.Context, redis *v1alpha1.Redis) ([]passwordTarget, error) {
+	pods, err := r.redisPods(ctx, redis)
+	if err != nil {
+		return nil, err
+	}
+	var targets []passwordTarget
+	for i := range pods {
+		if podRunning(&pods[i]) {
+			targets = append(targets, passwordTarget{pod: &pods[i], port: *redis.Spec.Port})
+		}
+	}
+
+	if !hasSentinel(redis) {
+		return targets, nil
+	}
+	sentinelPods := &corev1.PodList{}
+	if err := r.List(ctx, sentinelPods, client.InNamespace(redis.Namespace), client.MatchingLabels(sentinelLabels(redis.Name))); err != nil {
+		return nil, err
+	}
+	for i := range sentinelPods.Items {
+		if containerRunning(&sentinelPods.Items[i], "sentinel") {
+			targets = append(targets, passwordTarget{pod: &sentinelPods.Items[i], port: sentinelPort, sentinel: true})
+		}
+	}
+	return targets, nil
+}
+
+// rotationDue reports whether a password rotation is due and otherwise how long until it is, or zero if no
+// rotation is scheduled. Without an interval a rotation is only due when requested through the annotation.
+// The first interval starts when the password secret was created.
+func rotationDue(redis *v1alpha1.Redis, secret *corev1.Secret, now time.Time) (bool, time.Duration) {
+	if _, ok := redis.Annotations[rotatePasswordAnnotation]; ok {
+		return true, 0
+	}
+	interval := redis.Spec.PasswordRotation.Interval
+	if interval == nil || interval.Duration <= 0 {
+		return false, 0
+	}
+
+	last := secret.CreationTimestamp.Time
+	if redis.Status.LastPasswordRotationTime != nil {
+		last = redis.Status.LastPasswordRotationTime.Time
+	}
+	wait := last.Add(interval.Duration).Sub(now)
+	return wait <= 0, max(wait, 0)
+}
+
+// rotatesPassword reports whether the password of the instance is rotated by the operator.
+func rotatesPassword(redis *v1alpha1.Redis) bool {
+	return redis.Spec.PasswordRotation != nil
+}
+
+// addAuthVolume projects the password secret into the given container of a pod template.
+func addAuthVolume(redis *v1alpha1.Redis, spec *corev1.PodSpec, container *corev1.Container) {
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: authVolumeName, MountPath: redisAuthDir, ReadOnly: true})
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: authVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName: redis.Spec.PasswordSecretName,
+			Items:      []corev1.KeyToPath{{Key: v1alpha1.PasswordSecretKey(redis), Path: "password"}},
+		}},
+	})
+}
+
+// dialRedis returns a client authenticated with the first of the given passwords the server accepts.
+func dialRedis(ctx context.Context, host string, port int32, tlsConfig *tls.Config, passwords ...string) (*goredis.Client, error) {
+	var err error
+	for _, password := range passwords {
+		rdb := newRedisClient(host, port, password, tlsConfig)
+		if err = rdb.Ping(ctx).Err(); err == nil {
+			return rdb, nil
+		}
+		_ = rdb.Close()
+	}
+	return nil, err
+}
+
+func ptrTime(t metav1.Time) *metav1.Time {
+	return &t
+}
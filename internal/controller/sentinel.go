@@ -0,0 +1,329 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	goredis "github.com/redis/go-redis/v9"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// sentinelPort is the port Redis Sentinel listens on.
+	sentinelPort = 26379
+	// sentinelConfigKey is the config map key holding the rendered sentinel.conf.
+	sentinelConfigKey = "sentinel.conf"
+)
+
+// reconcileSentinel ensures the Sentinel config map, service and deployment exist when spec.sentinel is set,
+// and removes them once it is unset.
+func (r *RedisReconciler) reconcileSentinel(ctx context.Context, redis *v1alpha1.Redis) error {
+	if !hasSentinel(redis) {
+		objectMeta := metav1.ObjectMeta{Name: sentinelName(redis), Namespace: redis.Namespace}
+		for _, obj := range []client.Object{
+			&appsv1.Deployment{ObjectMeta: objectMeta},
+			&corev1.Service{ObjectMeta: objectMeta},
+			&corev1.ConfigMap{ObjectMeta: objectMeta},
+		} {
+			if err := r.deleteIfOwned(ctx, redis, obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, err := r.ensureConfigMap(ctx, redis, r.sentinelConfigMapForRedis(redis)); err != nil {
+		return err
+	}
+	if _, err := r.ensureService(ctx, redis, r.sentinelServiceForRedis(redis)); err != nil {
+		return err
+	}
+	// Sentinel consumes the password and TLS secrets too, so it is rolled along with the Redis pods.
+	checksum, err := r.podChecksum(ctx, redis)
+	if err != nil {
+		return err
+	}
+	if _, err := r.ensureDeployment(ctx, redis, r.sentinelDeploymentForRedis(redis, checksum)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sentinelPrimary asks every running Sentinel for the address of the primary and returns the pod
+// a majority of them agrees on, or nil if Sentinel has no settled view yet.
+func (r *RedisReconciler) sentinelPrimary(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod, password string) (*corev1.Pod, error) {
+	logger := log.FromContext(ctx)
+
+	sentinelPods := &corev1.PodList{}
+	if err := r.List(ctx, sentinelPods, client.InNamespace(redis.Namespace), client.MatchingLabels(sentinelLabels(redis.Name))); err != nil {
+		return nil, err
+	}
+
+	votes := map[string]int{}
+	answers := 0
+	for i := range sentinelPods.Items {
+		sentinelPod := &sentinelPods.Items[i]
+		if !containerRunning(sentinelPod, "sentinel") {
+			continue
+		}
+		host, err := r.querySentinel(ctx, redis, sentinelPod, password)
+		if err != nil {
+			logger.Error(err, "Unable to query Sentinel", "Pod.Name", sentinelPod.Name)
+			continue
+		}
+		answers++
+		if pod := podForHost(redis, pods, host); pod != nil {
+			votes[pod.Name]++
+		}
+	}
+
+	for i := range pods {
+		if votes[pods[i].Name]*2 > answers {
+			return &pods[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// querySentinel returns the primary host known to a single Sentinel. Sentinels that still remember
+// replaced Sentinel pods are reset, since stale peers raise the majority needed to authorize a failover.
+func (r *RedisReconciler) querySentinel(ctx context.Context, redis *v1alpha1.Redis, sentinelPod *corev1.Pod, password string) (string, error) {
+	sentinel := newSentinelClient(sentinelPod.Status.PodIP, password, r.clientTLSConfig(redis))
+	defer func() { _ = sentinel.Close() }()
+
+	addr, err := sentinel.GetMasterAddrByName(ctx, redis.Name).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(addr) != 2 {
+		return "", fmt.Errorf("unexpected primary address %v", addr)
+	}
+
+	master, err := sentinel.Master(ctx, redis.Name).Result()
+	if err != nil {
+		return "", err
+	}
+	if others, err := strconv.Atoi(master["num-other-sentinels"]); err == nil && int32(others) > *redis.Spec.Sentinel.Replicas-1 {
+		if err := sentinel.Reset(ctx, redis.Name).Err(); err != nil {
+			return "", err
+		}
+		log.FromContext(ctx).Info("Reset Sentinel with stale peers", "Pod.Name", sentinelPod.Name, "Peers", others)
+	}
+
+	return addr[0], nil
+}
+
+// podForHost maps an address reported by Redis or Sentinel, either a pod DNS name or a pod IP, to its pod.
+func podForHost(redis *v1alpha1.Redis, pods []corev1.Pod, host string) *corev1.Pod {
+	for i := range pods {
+		if host == podHost(redis, pods[i].Name) || host == pods[i].Status.PodIP || strings.HasPrefix(host, pods[i].Name+".") {
+			return &pods[i]
+		}
+	}
+	return nil
+}
+
+// newSentinelClient returns a client for the Sentinel listening on the given host, connecting over TLS if
+// a TLS configuration is given.
+func newSentinelClient(host string, password string, tlsConfig *tls.Config) *goredis.SentinelClient {
+	return goredis.NewSentinelClient(&goredis.Options{
+		Addr:         net.JoinHostPort(host, strconv.Itoa(sentinelPort)),
+		Password:     password,
+		TLSConfig:    tlsConfig,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisCommandTimeout,
+		WriteTimeout: redisCommandTimeout,
+		PoolSize:     1,
+		MaxRetries:   -1,
+	})
+}
+
+// sentinelConfigMapForRedis returns the config map holding the Sentinel configuration template.
+// Passwords are appended when the Sentinel starts, so they never end up in the config map.
+func (r *RedisReconciler) sentinelConfigMapForRedis(redis *v1alpha1.Redis) *corev1.ConfigMap {
+	sentinel := redis.Spec.Sentinel
+	primary := redis.Status.Primary
+	if primary == "" {
+		primary = fmt.Sprintf("%s-0", redis.Name)
+	}
+
+	lines := []string{fmt.Sprintf("port %d", sentinelPort)}
+	if hasTLS(redis) {
+		lines = []string{"port 0", fmt.Sprintf("tls-port %d", sentinelPort)}
+		// Sentinel reads the same directives from its config file that redis-server takes as arguments.
+		args := tlsServerArgs(redis)
+		for i := 0; i+1 < len(args); i += 2 {
+			lines = append(lines, fmt.Sprintf("%s %s", strings.TrimPrefix(args[i], "--"), args[i+1]))
+		}
+	}
+	config := strings.Join(append(lines,
+		fmt.Sprintf("dir %s", redisDataDir),
+		"sentinel resolve-hostnames yes",
+		"sentinel announce-hostnames yes",
+		fmt.Sprintf("sentinel monitor %s %s %d %d", redis.Name, podHost(redis, primary), *redis.Spec.Port, sentinel.Quorum),
+		fmt.Sprintf("sentinel down-after-milliseconds %s %d", redis.Name, sentinel.DownAfterMilliseconds),
+		fmt.Sprintf("sentinel failover-timeout %s %d", redis.Name, sentinel.FailoverTimeout),
+		fmt.Sprintf("sentinel parallel-syncs %s 1", redis.Name),
+	), "\n") + "\n"
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: sentinelName(redis), Namespace: redis.Namespace, Labels: sentinelLabels(redis.Name)},
+		Data:       map[string]string{sentinelConfigKey: config},
+	}
+
+	_ = ctrl.SetControllerReference(redis, cm, r.Scheme)
+
+	return cm
+}
+
+// sentinelServiceForRedis returns the Service exposing the Sentinel pods.
+func (r *RedisReconciler) sentinelServiceForRedis(redis *v1alpha1.Redis) *corev1.Service {
+	labels := sentinelLabels(redis.Name)
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: sentinelName(redis), Namespace: redis.Namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Port: sentinelPort, TargetPort: intstr.FromInt32(sentinelPort), Name: "sentinel"}},
+			Type:     corev1.ServiceTypeClusterIP,
+		},
+	}
+
+	_ = ctrl.SetControllerReference(redis, svc, r.Scheme)
+
+	return svc
+}
+
+// sentinelDeploymentForRedis returns the Deployment running Redis Sentinel. The checksum of the consumed
+// secrets is stamped on its pod template.
+func (r *RedisReconciler) sentinelDeploymentForRedis(redis *v1alpha1.Redis, checksum string) *appsv1.Deployment {
+	labels := sentinelLabels(redis.Name)
+	passwordRef := &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: redis.Spec.PasswordSecretName},
+			Key:                  v1alpha1.PasswordSecretKey(redis),
+		},
+	}
+
+	// Sentinel rewrites its configuration file, so the template is copied to a writable volume first.
+	script := fmt.Sprintf(`cp /etc/sentinel/%[1]s %[2]s/%[1]s && \
+printf 'sentinel auth-pass %[3]s %%s\nrequirepass %%s\nsentinel sentinel-pass %%s\n' "$REDIS_PASSWORD" "$REDIS_PASSWORD" "$REDIS_PASSWORD" >> %[2]s/%[1]s && \
+exec redis-server %[2]s/%[1]s --sentinel`, sentinelConfigKey, redisDataDir, redis.Name)
+
+	dep := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sentinelName(redis),
+			Namespace: redis.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: redis.Spec.Sentinel.Replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Image:   redis.Spec.Image,
+						Name:    "sentinel",
+						Command: []string{"sh", "-c", script},
+						Ports:   []corev1.ContainerPort{{ContainerPort: sentinelPort, Name: "sentinel"}},
+						Env: []corev1.EnvVar{
+							{Name: "REDIS_PASSWORD", ValueFrom: passwordRef},
+							{Name: "REDISCLI_AUTH", ValueFrom: passwordRef},
+						},
+						Resources: redis.Spec.Sentinel.Resources,
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								Exec: &corev1.ExecAction{Command: append(v1alpha1.RedisCLI(redis), "-p", strconv.Itoa(sentinelPort), "ping")},
+							},
+							InitialDelaySeconds: 5,
+							TimeoutSeconds:      1,
+							PeriodSeconds:       10,
+							FailureThreshold:    3,
+						},
+						LivenessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt32(sentinelPort)},
+							},
+							InitialDelaySeconds: 15,
+							TimeoutSeconds:      1,
+							PeriodSeconds:       20,
+							FailureThreshold:    3,
+						},
+						VolumeMounts: []corev1.VolumeMount{
+							{Name: "config", MountPath: "/etc/sentinel"},
+							{Name: dataVolumeName, MountPath: redisDataDir},
+						},
+					}},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: sentinelName(redis)},
+							}},
+						},
+						{
+							Name:         dataVolumeName,
+							VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	spec := &dep.Spec.Template.Spec
+	if rotatesPassword(redis) {
+		spec.Containers[0].ReadinessProbe = v1alpha1.PasswordProbe(redis, sentinelPort)
+		addAuthVolume(redis, spec, &spec.Containers[0])
+	}
+	if hasTLS(redis) {
+		addTLSVolume(redis, spec, &spec.Containers[0])
+	}
+	dep.Spec.Template = withChecksum(dep.Spec.Template, checksum)
+
+	_ = ctrl.SetControllerReference(redis, dep, r.Scheme)
+
+	return dep
+}
+
+// sentinelName returns the name shared by the Sentinel deployment, service and config map.
+func sentinelName(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-sentinel", redis.Name)
+}
+
+func sentinelLabels(name string) map[string]string {
+	return map[string]string{"app": "redis-sentinel", "redis_cr": name}
+}
+
+// hasSentinel reports whether the Redis instance is monitored by Sentinel.
+func hasSentinel(redis *v1alpha1.Redis) bool {
+	return redis.Spec.Sentinel != nil && isReplicated(redis)
+}
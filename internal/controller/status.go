@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// redisPhase summarizes the readiness of the Redis pods. A rollout takes precedence over missing pods,
+// since pods are expected to be unready while they are replaced.
+func redisPhase(readyReplicas int32, desiredReplicas int32, rolling bool) v1alpha1.Phase {
+	switch {
+	case readyReplicas == 0:
+		return v1alpha1.PhasePending
+	case rolling:
+		return v1alpha1.PhaseUpdating
+	case readyReplicas < desiredReplicas:
+		return v1alpha1.PhaseDegraded
+	default:
+		return v1alpha1.PhaseReady
+	}
+}
+
+// podStatuses returns the name, role and readiness of every Redis pod. Every pod of a standalone instance
+// accepts writes, so they are reported as primaries; other pods report the role they are labelled with.
+func podStatuses(redis *v1alpha1.Redis, pods []corev1.Pod) []v1alpha1.RedisPodStatus {
+	var statuses []v1alpha1.RedisPodStatus
+	for i := range pods {
+		pod := &pods[i]
+		role := pod.Labels[roleLabel]
+		if !isReplicated(redis) && !isClustered(redis) {
+			role = rolePrimary
+		}
+		statuses = append(statuses, v1alpha1.RedisPodStatus{Name: pod.Name, Role: role, Ready: podReady(pod)})
+	}
+	return statuses
+}
+
+// podReady reports whether a pod passes its readiness probe.
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// runningVersion reads the Redis version with INFO server, preferring the primary over the other pods.
+// It returns an empty string if no running pod answers, so the last known version is kept.
+func (r *RedisReconciler) runningVersion(ctx context.Context, redis *v1alpha1.Redis, pods []corev1.Pod) string {
+	logger := log.FromContext(ctx)
+
+	candidates := make([]*corev1.Pod, 0, len(pods))
+	for i := range pods {
+		if !podRunning(&pods[i]) {
+			continue
+		}
+		if pods[i].Name == redis.Status.Primary {
+			candidates = append([]*corev1.Pod{&pods[i]}, candidates...)
+		} else {
+			candidates = append(candidates, &pods[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	password, err := r.redisPassword(ctx, redis)
+	if err != nil {
+		logger.Error(err, "unable to read the Redis password to query the version")
+		return ""
+	}
+	for _, pod := range candidates {
+		rdb := redisClientForPod(redis, pod, password, r.clientTLSConfig(redis))
+		info, err := redisInfo(ctx, rdb, "server")
+		_ = rdb.Close()
+		if err != nil {
+			logger.Info("Unable to query the Redis version", "Pod", pod.Name, "error", err.Error())
+			continue
+		}
+		if version := info["redis_version"]; version != "" {
+			return version
+		}
+	}
+	return ""
+}
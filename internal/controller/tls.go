@@ -0,0 +1,436 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// tlsVolumeName is the name of the volume projecting the TLS secret into the pods.
+	tlsVolumeName = "tls"
+	// redisTLSDir is the directory the TLS secret is projected to.
+	redisTLSDir = v1alpha1.TLSMountPath
+	// caCertKey is the secret key holding the CA certificate, next to tls.crt and tls.key.
+	caCertKey = v1alpha1.TLSCACertKey
+	// caKeyKey is the secret key holding the private key of the generated CA.
+	caKeyKey = "ca.key"
+
+	// tlsCertificateDuration is how long generated server certificates are valid.
+	tlsCertificateDuration = 365 * 24 * time.Hour
+	// tlsCADuration is how long generated CAs are valid. The CA outlives many server certificates,
+	// so clients trusting it keep working across renewals.
+	tlsCADuration = 10 * 365 * 24 * time.Hour
+	// tlsRenewBefore is how long before expiry generated server certificates are renewed.
+	tlsRenewBefore = 30 * 24 * time.Hour
+)
+
+// clientTLSConfigs caches the TLS configuration the Redis controller connects to every TLS-enabled instance
+// with, keyed by the namespaced name of the instance. reconcileTLS refreshes an entry whenever the instance
+// is reconciled, and finalizeRedis evicts it. The zero value is ready to use.
+type clientTLSConfigs struct {
+	configs sync.Map
+}
+
+// get returns the cached TLS configuration of the instance, or nil if none is cached.
+func (c *clientTLSConfigs) get(redis *v1alpha1.Redis) *tls.Config {
+	config, ok := c.configs.Load(client.ObjectKeyFromObject(redis))
+	if !ok {
+		return nil
+	}
+	return config.(*tls.Config)
+}
+
+// set caches the TLS configuration of the instance.
+func (c *clientTLSConfigs) set(redis *v1alpha1.Redis, config *tls.Config) {
+	c.configs.Store(client.ObjectKeyFromObject(redis), config)
+}
+
+// forget evicts the cached TLS configuration of the instance.
+func (c *clientTLSConfigs) forget(redis *v1alpha1.Redis) {
+	c.configs.Delete(client.ObjectKeyFromObject(redis))
+}
+
+// reconcileTLS ensures the TLS secret of the instance exists and, if generated by the operator, is renewed
+// before it expires. Renewing the secret changes the pod checksum, which rolls the pods onto the new
+// certificate. It returns the delay until the generated certificate is due for renewal, or zero.
+func (r *RedisReconciler) reconcileTLS(ctx context.Context, redis *v1alpha1.Redis) (time.Duration, error) {
+	if !hasTLS(redis) || redis.Spec.TLS.SecretName != "" {
+		if err := r.deleteIfOwned(ctx, redis, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: generatedTLSSecretName(redis), Namespace: redis.Namespace}}); err != nil {
+			return 0, err
+		}
+	}
+	if !hasTLS(redis) {
+		r.tlsConfigs.forget(redis)
+		if redis.Status.TLSCertificateNotAfter == nil {
+			return 0, nil
+		}
+		base := redis.DeepCopy()
+		redis.Status.TLSCertificateNotAfter = nil
+		return 0, r.Status().Patch(ctx, redis, client.MergeFrom(base))
+	}
+
+	generated := redis.Spec.TLS.SecretName == ""
+	if generated {
+		if err := r.ensureTLSSecret(ctx, redis); err != nil {
+			return 0, err
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: tlsSecretName(redis), Namespace: redis.Namespace}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			r.Recorder.Event(redis, corev1.EventTypeWarning, "MissingTLSSecret", fmt.Sprintf("TLS secret %s does not exist", tlsSecretName(redis)))
+		}
+		return 0, err
+	}
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "InvalidTLSSecret", fmt.Sprintf("TLS secret %s: %v", secret.Name, err))
+		return 0, err
+	}
+	config, err := newClientTLSConfig(redis, secret)
+	if err != nil {
+		r.Recorder.Event(redis, corev1.EventTypeWarning, "InvalidTLSSecret", fmt.Sprintf("TLS secret %s: %v", secret.Name, err))
+		return 0, err
+	}
+	r.tlsConfigs.set(redis, config)
+
+	if notAfter := redis.Status.TLSCertificateNotAfter; notAfter == nil || !notAfter.Time.Equal(cert.NotAfter) {
+		base := redis.DeepCopy()
+		redis.Status.TLSCertificateNotAfter = &metav1.Time{Time: cert.NotAfter}
+		if err := r.Status().Patch(ctx, redis, client.MergeFrom(base)); err != nil {
+			return 0, err
+		}
+	}
+
+	// Certificates from a referenced secret are renewed by whoever owns it; the secret watch rolls the pods.
+	if !generated {
+		return 0, nil
+	}
+	return max(time.Until(cert.NotAfter.Add(-tlsRenewBefore)), time.Second), nil
+}
+
+// ensureTLSSecret issues the server certificate into the generated TLS secret when it is missing, due for
+// renewal or no longer covers the DNS names of the instance. The CA is kept as long as it outlives the new
+// certificate, so clients trusting it are unaffected by renewals.
+func (r *RedisReconciler) ensureTLSSecret(ctx context.Context, redis *v1alpha1.Redis) error {
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: generatedTLSSecretName(redis), Namespace: redis.Namespace}, secret)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	dnsNames := tlsDNSNames(redis)
+	now := time.Now()
+	if found && !certificateNeedsRenewal(secret.Data[corev1.TLSCertKey], dnsNames, now) {
+		return nil
+	}
+
+	data, err := issueCertificate(redis, secret.Data, dnsNames, now)
+	if err != nil {
+		return fmt.Errorf("failed to issue TLS certificate: %w", err)
+	}
+
+	if !found {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: generatedTLSSecretName(redis), Namespace: redis.Namespace, Labels: labelsForRedis(redis.Name)},
+			Type:       corev1.SecretTypeTLS,
+			Data:       data,
+		}
+		if err := ctrl.SetControllerReference(redis, secret, r.Scheme); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, secret); err != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "IssuedCertificate", fmt.Sprintf("Issued TLS certificate into secret %s", secret.Name))
+		return nil
+	}
+
+	patch := client.MergeFrom(secret.DeepCopy())
+	secret.Data = data
+	if err := r.Patch(ctx, secret, patch); err != nil {
+		return err
+	}
+	r.Recorder.Event(redis, corev1.EventTypeNormal, "RenewedCertificate", fmt.Sprintf("Renewed TLS certificate in secret %s", secret.Name))
+	return nil
+}
+
+// certificateNeedsRenewal reports whether a PEM encoded server certificate is unusable, expires within the
+// renewal window or does not cover exactly the given DNS names.
+func certificateNeedsRenewal(certPEM []byte, dnsNames []string, now time.Time) bool {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return true
+	}
+	if now.Add(tlsRenewBefore).After(cert.NotAfter) {
+		return true
+	}
+	names := slices.Clone(cert.DNSNames)
+	sort.Strings(names)
+	return !slices.Equal(names, dnsNames)
+}
+
+// issueCertificate issues a server certificate for the given DNS names, signed by the CA found in the existing
+// secret data if it is still valid past the new certificate, or by a newly generated CA otherwise.
+// It returns the data of the TLS secret.
+func issueCertificate(redis *v1alpha1.Redis, existing map[string][]byte, dnsNames []string, now time.Time) (map[string][]byte, error) {
+	notAfter := now.Add(tlsCertificateDuration)
+
+	caCert, caKey, err := parseCA(existing[caCertKey], existing[caKeyKey])
+	if err != nil || caCert.NotAfter.Before(notAfter) {
+		caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		template := &x509.Certificate{
+			SerialNumber:          newSerialNumber(),
+			Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-ca", redis.Name)},
+			NotBefore:             now.Add(-time.Hour),
+			NotAfter:              now.Add(tlsCADuration),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+		if err != nil {
+			return nil, err
+		}
+		if caCert, err = x509.ParseCertificate(der); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	// Redis nodes present the same certificate when connecting to each other, so it is valid for clients too.
+	template := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{CommonName: serviceHost(redis)},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyDER, err := x509.MarshalECPrivateKey(caKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		caCertKey:               pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}),
+		caKeyKey:                pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: caKeyDER}),
+	}, nil
+}
+
+// parseCA parses the PEM encoded certificate and private key of a generated CA.
+func parseCA(certPEM []byte, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM encoded CA key")
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// parseCertificate parses the first certificate of a PEM encoded chain.
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// newSerialNumber returns a random certificate serial number.
+func newSerialNumber() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return serial
+}
+
+// tlsDNSNames returns the sorted DNS names the server certificate of the instance must cover: every service
+// of the instance and, for StatefulSets, every pod behind the headless service.
+func tlsDNSNames(redis *v1alpha1.Redis) []string {
+	services := []string{redis.Spec.Service.Name}
+	if readService := redis.Spec.Service.ReadService; readService != nil && isReplicated(redis) {
+		services = append(services, readService.Name)
+	}
+	if hasSentinel(redis) {
+		services = append(services, sentinelName(redis))
+	}
+	if isStatefulSet(redis) {
+		services = append(services, headlessServiceName(redis))
+	}
+
+	names := []string{"localhost"}
+	for _, service := range services {
+		host := fmt.Sprintf("%s.%s.svc", service, redis.Namespace)
+		names = append(names, service, fmt.Sprintf("%s.%s", service, redis.Namespace), host, host+".cluster.local")
+	}
+	if isStatefulSet(redis) {
+		names = append(names, "*."+headlessServiceHost(redis), "*."+headlessServiceHost(redis)+".cluster.local")
+	}
+	sort.Strings(names)
+	return slices.Compact(names)
+}
+
+// newClientTLSConfig builds the TLS configuration the operator connects to the instance with from its TLS
+// secret. The operator connects to pod IPs, so the server certificate is verified against the service DNS
+// name every pod serves.
+func newClientTLSConfig(redis *v1alpha1.Redis, secret *corev1.Secret) (*tls.Config, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data[caCertKey]) {
+		return nil, fmt.Errorf("no PEM encoded CA certificate under %s", caCertKey)
+	}
+	config := &tls.Config{
+		RootCAs:    pool,
+		ServerName: serviceHost(redis),
+		MinVersion: tls.VersionTLS12,
+	}
+	if redis.Spec.TLS.AuthClients {
+		cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// readClientTLSConfig builds the TLS configuration to connect to the instance with from its TLS secret, or
+// returns nil without TLS. Controllers other than the Redis controller call it before connecting to the instance.
+func readClientTLSConfig(ctx context.Context, c client.Reader, redis *v1alpha1.Redis) (*tls.Config, error) {
+	if !hasTLS(redis) {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: tlsSecretName(redis), Namespace: redis.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	return newClientTLSConfig(redis, secret)
+}
+
+// clientTLSConfig returns the TLS configuration to connect to the instance with, or nil without TLS.
+func (r *RedisReconciler) clientTLSConfig(redis *v1alpha1.Redis) *tls.Config {
+	if !hasTLS(redis) {
+		return nil
+	}
+	// Until reconcileTLS cached the configuration, connecting without TLS fails and is retried.
+	return r.tlsConfigs.get(redis)
+}
+
+// tlsServerArgs returns the redis-server arguments serving TLS from the projected TLS secret.
+func tlsServerArgs(redis *v1alpha1.Redis) []string {
+	authClients := "no"
+	if redis.Spec.TLS.AuthClients {
+		authClients = "yes"
+	}
+	args := []string{
+		"--tls-cert-file", fmt.Sprintf("%s/%s", redisTLSDir, corev1.TLSCertKey),
+		"--tls-key-file", fmt.Sprintf("%s/%s", redisTLSDir, corev1.TLSPrivateKeyKey),
+		"--tls-ca-cert-file", fmt.Sprintf("%s/%s", redisTLSDir, caCertKey),
+		"--tls-auth-clients", authClients,
+		"--tls-replication", "yes",
+	}
+	if isClustered(redis) {
+		args = append(args, "--tls-cluster", "yes")
+	}
+	return args
+}
+
+// addTLSVolume projects the TLS secret into the given container of a pod template.
+func addTLSVolume(redis *v1alpha1.Redis, spec *corev1.PodSpec, container *corev1.Container) {
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: tlsVolumeName, MountPath: redisTLSDir, ReadOnly: true})
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+			SecretName: tlsSecretName(redis),
+			Items: []corev1.KeyToPath{
+				{Key: corev1.TLSCertKey, Path: corev1.TLSCertKey},
+				{Key: corev1.TLSPrivateKeyKey, Path: corev1.TLSPrivateKeyKey},
+				{Key: caCertKey, Path: caCertKey},
+			},
+		}},
+	})
+}
+
+// serviceHost returns the in-cluster DNS name of the primary service of the instance.
+func serviceHost(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s.%s.svc", redis.Spec.Service.Name, redis.Namespace)
+}
+
+// tlsSecretName returns the name of the secret holding the TLS certificate of the instance.
+func tlsSecretName(redis *v1alpha1.Redis) string {
+	if redis.Spec.TLS != nil && redis.Spec.TLS.SecretName != "" {
+		return redis.Spec.TLS.SecretName
+	}
+	return generatedTLSSecretName(redis)
+}
+
+// generatedTLSSecretName returns the name of the TLS secret generated by the operator.
+func generatedTLSSecretName(redis *v1alpha1.Redis) string {
+	return fmt.Sprintf("%s-tls", redis.Name)
+}
+
+// hasTLS reports whether the instance serves TLS.
+func hasTLS(redis *v1alpha1.Redis) bool {
+	return redis.Spec.TLS != nil
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
 )
 
 const (
@@ -13,10 +18,32 @@ const (
 	RequeueDelay = 10 * time.Second
 )
 
+// Condition types set on the Redis status.
+const (
+	conditionAvailable       = "Available"
+	conditionStorageSynced   = "StorageSynced"
+	conditionVolumeResizing  = "VolumeResizing"
+	conditionResharding      = "Resharding"
+	conditionBackupSucceeded = "BackupSucceeded"
+	conditionRestored        = "Restored"
+	conditionFinalSnapshot   = "FinalSnapshot"
+)
+
 func reconciled() (ctrl.Result, error) {
 	return ctrl.Result{}, nil
 }
 
+// earliestDelay returns the shortest of the given requeue delays, ignoring zero delays, or zero if all are zero.
+func earliestDelay(delays ...time.Duration) time.Duration {
+	var earliest time.Duration
+	for _, delay := range delays {
+		if delay > 0 && (earliest == 0 || delay < earliest) {
+			earliest = delay
+		}
+	}
+	return earliest
+}
+
 func requeueInstanceWithError(ctx context.Context, instanceName string, namespace string, err error) (ctrl.Result, error) {
 	logf.FromContext(ctx).Error(err, "Re-queuing Redis instance due to error", "name", instanceName, "namespace", namespace, "after", RequeueDelay)
 	return ctrl.Result{
@@ -24,3 +51,22 @@ func requeueInstanceWithError(ctx context.Context, instanceName string, namespac
 		RequeueAfter: RequeueDelay,
 	}, err
 }
+
+// setCondition patches a single status condition on the Redis CR if it changed.
+func (r *RedisReconciler) setCondition(ctx context.Context, redis *v1alpha1.Redis, condition metav1.Condition) error {
+	base := redis.DeepCopy()
+	condition.ObservedGeneration = redis.Generation
+	if !meta.SetStatusCondition(&redis.Status.Conditions, condition) {
+		return nil
+	}
+	return r.Status().Patch(ctx, redis, client.MergeFrom(base))
+}
+
+// removeCondition patches a single status condition away from the Redis CR if it is present.
+func (r *RedisReconciler) removeCondition(ctx context.Context, redis *v1alpha1.Redis, conditionType string) error {
+	base := redis.DeepCopy()
+	if !meta.RemoveStatusCondition(&redis.Status.Conditions, conditionType) {
+		return nil
+	}
+	return r.Status().Patch(ctx, redis, client.MergeFrom(base))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+const (
+	// defaultStorageClassAnnotation marks the storage class claims without a storage class name are provisioned from.
+	defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+	// betaDefaultStorageClassAnnotation is the deprecated beta variant of defaultStorageClassAnnotation.
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// reconcileVolumeExpansion grows the data claims of the Redis StatefulSet to spec.persistence.size.
+// StatefulSet claim templates are immutable, so every existing claim is patched individually and the
+// StatefulSet is recreated without its pods once all claims have been resized.
+func (r *RedisReconciler) reconcileVolumeExpansion(ctx context.Context, redis *v1alpha1.Redis, statefulSet *appsv1.StatefulSet) error {
+	logger := log.FromContext(ctx)
+	if redis.Spec.Persistence == nil || statefulSet == nil {
+		return r.removeCondition(ctx, redis, conditionVolumeResizing)
+	}
+
+	claims, err := r.dataClaimsForRedis(ctx, redis)
+	if err != nil {
+		return err
+	}
+	desiredSize := redis.Spec.Persistence.Size
+
+	var toExpand []*corev1.PersistentVolumeClaim
+	for i := range claims {
+		claim := &claims[i]
+		requested := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+		switch desiredSize.Cmp(requested) {
+		case -1:
+			message := fmt.Sprintf("Shrinking volume %s from %s to %s is not supported", claim.Name, requested.String(), desiredSize.String())
+			return r.setResizeFailed(ctx, redis, "VolumeShrinkRejected", message)
+		case 1:
+			toExpand = append(toExpand, claim)
+		}
+	}
+
+	if len(toExpand) > 0 {
+		claim := toExpand[0]
+		if name := claim.Spec.StorageClassName; name != nil && *name == "" {
+			message := fmt.Sprintf("Volume %s is statically provisioned without a storage class and cannot be expanded", claim.Name)
+			return r.setResizeFailed(ctx, redis, "VolumeExpansionNotSupported", message)
+		}
+		storageClass, err := r.storageClassForClaim(ctx, claim)
+		if err != nil {
+			return err
+		}
+		if storageClass == nil {
+			message := fmt.Sprintf("Storage class %q of volume %s does not exist", ptr.Deref(claim.Spec.StorageClassName, ""), claim.Name)
+			if claim.Spec.StorageClassName == nil {
+				message = fmt.Sprintf("Volume %s uses the default storage class, but none is marked as default", claim.Name)
+			}
+			return r.setResizeFailed(ctx, redis, "StorageClassNotFound", message)
+		}
+		if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+			message := fmt.Sprintf("Storage class %q does not allow volume expansion", storageClass.Name)
+			return r.setResizeFailed(ctx, redis, "VolumeExpansionNotSupported", message)
+		}
+
+		for _, claim := range toExpand {
+			patch := client.MergeFrom(claim.DeepCopy())
+			claim.Spec.Resources.Requests[corev1.ResourceStorage] = desiredSize
+			if err := r.Patch(ctx, claim, patch); err != nil {
+				return err
+			}
+			logger.Info("Expanding volume", "PersistentVolumeClaim.Name", claim.Name, "Size", desiredSize.String())
+			r.Recorder.Event(redis, corev1.EventTypeNormal, "ExpandingVolume", fmt.Sprintf("Expanding volume %s to %s", claim.Name, desiredSize.String()))
+		}
+	}
+
+	// Only bound claims report a capacity, so claims that are still being provisioned are not counted.
+	bound, resized := 0, 0
+	for _, claim := range claims {
+		if claim.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		bound++
+		capacity := claim.Status.Capacity[corev1.ResourceStorage]
+		if capacity.Cmp(desiredSize) >= 0 {
+			resized++
+		}
+	}
+
+	if resized < bound {
+		return r.setCondition(ctx, redis, metav1.Condition{
+			Type:    conditionVolumeResizing,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Resizing",
+			Message: fmt.Sprintf("%d of %d volumes resized to %s", resized, bound, desiredSize.String()),
+		})
+	}
+
+	// Every claim has its new capacity, so the claim template can catch up. Recreating the StatefulSet
+	// with an orphaning delete keeps the pods running and lets the replacement adopt them unchanged.
+	if reason, _ := storageDrift(statefulSet, redis); reason == "StorageSizeChanged" && len(claims) > 0 {
+		logger.Info("Volumes resized, recreating StatefulSet to update its claim template", "StatefulSet.Name", statefulSet.Name)
+		if err := r.Delete(ctx, statefulSet, client.PropagationPolicy(metav1.DeletePropagationOrphan)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		r.Recorder.Event(redis, corev1.EventTypeNormal, "RecreatingStatefulSet", fmt.Sprintf("Recreating statefulset %s to update its claim template to %s", statefulSet.Name, desiredSize.String()))
+	}
+
+	if meta.FindStatusCondition(redis.Status.Conditions, conditionVolumeResizing) == nil {
+		return nil
+	}
+	return r.setCondition(ctx, redis, metav1.Condition{
+		Type:    conditionVolumeResizing,
+		Status:  metav1.ConditionFalse,
+		Reason:  "ResizeComplete",
+		Message: fmt.Sprintf("All volumes resized to %s", desiredSize.String()),
+	})
+}
+
+// setResizeFailed records a rejected volume resize as an event and on the VolumeResizing condition.
+func (r *RedisReconciler) setResizeFailed(ctx context.Context, redis *v1alpha1.Redis, reason string, message string) error {
+	condition := meta.FindStatusCondition(redis.Status.Conditions, conditionVolumeResizing)
+	if condition == nil || condition.Reason != reason {
+		r.Recorder.Event(redis, corev1.EventTypeWarning, reason, message)
+	}
+	return r.setCondition(ctx, redis, metav1.Condition{
+		Type:    conditionVolumeResizing,
+		Status:  metav1.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+}
+
+// dataClaimsForRedis lists the data volume claims created from the Redis StatefulSet claim template.
+func (r *RedisReconciler) dataClaimsForRedis(ctx context.Context, redis *v1alpha1.Redis) ([]corev1.PersistentVolumeClaim, error) {
+	claimList := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, claimList, client.InNamespace(redis.Namespace), client.MatchingLabels(labelsForRedis(redis.Name))); err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", dataVolumeName, redis.Name)
+	claims := make([]corev1.PersistentVolumeClaim, 0, len(claimList.Items))
+	for _, claim := range claimList.Items {
+		if strings.HasPrefix(claim.Name, prefix) {
+			claims = append(claims, claim)
+		}
+	}
+	return claims, nil
+}
+
+// storageClassForClaim returns the storage class of a claim, or nil if it does not exist. Claims without a
+// storage class name use the default storage class, the one carrying the is-default-class annotation.
+func (r *RedisReconciler) storageClassForClaim(ctx context.Context, claim *corev1.PersistentVolumeClaim) (*storagev1.StorageClass, error) {
+	if name := claim.Spec.StorageClassName; name != nil {
+		storageClass := &storagev1.StorageClass{}
+		if err := r.Get(ctx, types.NamespacedName{Name: *name}, storageClass); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return storageClass, nil
+	}
+
+	storageClassList := &storagev1.StorageClassList{}
+	if err := r.List(ctx, storageClassList); err != nil {
+		return nil, err
+	}
+	for i := range storageClassList.Items {
+		storageClass := &storageClassList.Items[i]
+		for _, annotation := range []string{defaultStorageClassAnnotation, betaDefaultStorageClassAnnotation} {
+			if storageClass.Annotations[annotation] == "true" {
+				return storageClass, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+// log is for logging in this package.
+var redislog = logf.Log.WithName("redis-resource")
+
+// reservedEnvVars are set by the operator on the Redis container and must not be overridden by spec.env.
+var reservedEnvVars = []string{"REDIS_PASSWORD", "REDISCLI_AUTH", "POD_NAME"}
+
+// SetupRedisWebhookWithManager registers the webhook for Redis in the manager.
+func SetupRedisWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&redisv1alpha1.Redis{}).
+		WithValidator(&RedisCustomValidator{Client: mgr.GetAPIReader()}).
+		WithDefaulter(&RedisCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-redis-yazio-com-v1alpha1-redis,mutating=true,failurePolicy=fail,sideEffects=None,groups=redis.yazio.com,resources=redis,verbs=create;update,versions=v1alpha1,name=mredis-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// RedisCustomDefaulter sets default values on the Redis resource when it is created or updated,
+// so the stored object shows the image, probes and resources the pods actually run with.
+type RedisCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &RedisCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type Redis.
+func (d *RedisCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	redis, ok := obj.(*redisv1alpha1.Redis)
+	if !ok {
+		return fmt.Errorf("expected a Redis object but got %T", obj)
+	}
+	redislog.Info("Defaulting for Redis", "name", redis.GetName())
+
+	old, err := oldRedis(ctx)
+	if err != nil {
+		return err
+	}
+	if old != nil {
+		// Probes still holding the defaults of the old spec were not chosen by the user,
+		// so they follow changes such as a new port or enabling TLS.
+		if equality.Semantic.DeepEqual(redis.Spec.ReadinessProbe, redisv1alpha1.DefaultReadinessProbe(old)) {
+			redis.Spec.ReadinessProbe = nil
+		}
+		if equality.Semantic.DeepEqual(redis.Spec.LivenessProbe, redisv1alpha1.DefaultLivenessProbe(old)) {
+			redis.Spec.LivenessProbe = nil
+		}
+	}
+	// Only new images are resolved, so running instances are not rolled onto another version.
+	if old == nil || old.Spec.Image != redis.Spec.Image {
+		redis.Spec.Image = redisv1alpha1.ImageWithTag(redis.Spec.Image)
+	}
+	redisv1alpha1.SetDefaults(redis)
+
+	return nil
+}
+
+// oldRedis returns the stored object of an update admission request, or nil on creation.
+func oldRedis(ctx context.Context) (*redisv1alpha1.Redis, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || len(req.OldObject.Raw) == 0 {
+		return nil, nil
+	}
+	old := &redisv1alpha1.Redis{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return nil, fmt.Errorf("failed to decode the stored Redis: %w", err)
+	}
+	return old, nil
+}
+
+// +kubebuilder:webhook:path=/validate-redis-yazio-com-v1alpha1-redis,mutating=false,failurePolicy=fail,sideEffects=None,groups=redis.yazio.com,resources=redis,verbs=create;update,versions=v1alpha1,name=vredis-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// RedisCustomValidator validates the Redis resource when it is created, updated, or deleted.
+// It enforces the rules spanning several fields or the old and new object, which the CRD schema cannot express.
+type RedisCustomValidator struct {
+	// Client reads the secrets a Redis is switched to.
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &RedisCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Redis.
+func (v *RedisCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	redis, ok := obj.(*redisv1alpha1.Redis)
+	if !ok {
+		return nil, fmt.Errorf("expected a Redis object but got %T", obj)
+	}
+	redislog.Info("Validation for Redis upon creation", "name", redis.GetName())
+
+	return specWarnings(redis), invalid(redis, validateSpec(redis))
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Redis.
+func (v *RedisCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	redis, ok := newObj.(*redisv1alpha1.Redis)
+	if !ok {
+		return nil, fmt.Errorf("expected a Redis object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*redisv1alpha1.Redis)
+	if !ok {
+		return nil, fmt.Errorf("expected a Redis object for the oldObj but got %T", oldObj)
+	}
+	redislog.Info("Validation for Redis upon update", "name", redis.GetName())
+
+	// Leave objects that are being deleted alone, so removing finalizers is never blocked.
+	if !redis.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	// Only spec changes are validated. The operator keeps updating finalizers and annotations of objects
+	// stored before a rule was added, which must not be blocked by a spec that no longer validates.
+	if equality.Semantic.DeepEqual(old.Spec, redis.Spec) {
+		return nil, nil
+	}
+
+	allErrs := validateSpec(redis)
+	allErrs = append(allErrs, validateTransition(old, redis)...)
+	passwordErrs, err := v.validatePasswordSecret(ctx, old, redis)
+	if err != nil {
+		return nil, err
+	}
+	allErrs = append(allErrs, passwordErrs...)
+
+	return specWarnings(redis), invalid(redis, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Redis.
+// Deletion is always allowed, so no webhook verb is registered for it.
+func (v *RedisCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSpec checks the rules every Redis spec has to satisfy.
+func validateSpec(redis *redisv1alpha1.Redis) field.ErrorList {
+	var allErrs field.ErrorList
+	spec := field.NewPath("spec")
+
+	checkPort := func(path *field.Path, port *int32) {
+		if port != nil && *port == redisv1alpha1.ExporterPort {
+			allErrs = append(allErrs, field.Invalid(path, *port,
+				fmt.Sprintf("port %d is reserved for the metrics exporter", redisv1alpha1.ExporterPort)))
+		}
+	}
+	checkPort(spec.Child("port"), redis.Spec.Port)
+	checkPort(spec.Child("service", "port"), redis.Spec.Service.Port)
+	if readService := redis.Spec.Service.ReadService; readService != nil {
+		checkPort(spec.Child("service", "readService", "port"), readService.Port)
+	}
+	// Redis derives the cluster bus port from the client port, so it has to stay a valid port.
+	if redis.Spec.Mode == redisv1alpha1.ModeCluster && redis.Spec.Port != nil && *redis.Spec.Port > 65535-10000 {
+		allErrs = append(allErrs, field.Invalid(spec.Child("port"), *redis.Spec.Port,
+			"in cluster mode the port plus 10000 is the cluster bus port and must not exceed 65535"))
+	}
+
+	reservedNames := map[string]string{
+		redis.Name + "-headless": "the headless service",
+		redis.Name + "-sentinel": "the sentinel service",
+	}
+	if reason, ok := reservedNames[redis.Spec.Service.Name]; ok {
+		allErrs = append(allErrs, field.Invalid(spec.Child("service", "name"), redis.Spec.Service.Name,
+			"name is used by "+reason))
+	}
+	if readService := redis.Spec.Service.ReadService; readService != nil {
+		path := spec.Child("service", "readService", "name")
+		if readService.Name == redis.Spec.Service.Name {
+			allErrs = append(allErrs, field.Invalid(path, readService.Name, "must differ from spec.service.name"))
+		} else if reason, ok := reservedNames[readService.Name]; ok {
+			allErrs = append(allErrs, field.Invalid(path, readService.Name, "name is used by "+reason))
+		}
+	}
+
+	if redis.Spec.Env != nil {
+		for i, env := range *redis.Spec.Env {
+			if slices.Contains(reservedEnvVars, env.Name) {
+				allErrs = append(allErrs, field.Forbidden(spec.Child("env").Index(i).Child("name"),
+					fmt.Sprintf("%s is set by the operator", env.Name)))
+			}
+		}
+	}
+
+	if rotation := redis.Spec.PasswordRotation; rotation != nil && rotation.Interval != nil &&
+		rotation.Interval.Duration <= rotation.GracePeriod.Duration {
+		allErrs = append(allErrs, field.Invalid(spec.Child("passwordRotation", "interval"), rotation.Interval.Duration.String(),
+			"must be longer than the grace period"))
+	}
+
+	if backup := redis.Spec.Backup; backup != nil {
+		if _, err := cron.ParseStandard(backup.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(spec.Child("backup", "schedule"), backup.Schedule, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// validateTransition checks the changes from the old to the new spec that the operator cannot carry out.
+func validateTransition(old, redis *redisv1alpha1.Redis) field.ErrorList {
+	var allErrs field.ErrorList
+	spec := field.NewPath("spec")
+
+	// Slots are only sharded in cluster mode, so the data cannot be carried over to or from it.
+	if (old.Spec.Mode == redisv1alpha1.ModeCluster) != (redis.Spec.Mode == redisv1alpha1.ModeCluster) {
+		allErrs = append(allErrs, field.Forbidden(spec.Child("mode"), "cannot change to or from cluster mode"))
+	}
+
+	// Volumes can only grow. A changed storage class is admitted and reported by the operator, since the
+	// existing volumes keep their class.
+	if old.Spec.Persistence != nil && redis.Spec.Persistence != nil {
+		if redis.Spec.Persistence.Size.Cmp(old.Spec.Persistence.Size) < 0 {
+			allErrs = append(allErrs, field.Forbidden(spec.Child("persistence", "size"),
+				fmt.Sprintf("cannot be decreased from %s", old.Spec.Persistence.Size.String())))
+		}
+	}
+
+	return allErrs
+}
+
+// validatePasswordSecret checks that a Redis switched to another password secret is switched to an existing one.
+// The operator would otherwise generate a new password, locking out clients and replicas of the running pods.
+func (v *RedisCustomValidator) validatePasswordSecret(ctx context.Context, old, redis *redisv1alpha1.Redis) (field.ErrorList, error) {
+	name := redis.Spec.PasswordSecretName
+	if name == old.Spec.PasswordSecretName {
+		return nil, nil
+	}
+	path := field.NewPath("spec", "passwordSecretName")
+
+	secret := &corev1.Secret{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: redis.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList{field.NotFound(path, name)}, nil
+		}
+		return nil, err
+	}
+	if key := redisv1alpha1.PasswordSecretKey(redis); len(secret.Data[key]) == 0 {
+		return field.ErrorList{field.Invalid(path, name, fmt.Sprintf("secret has no %s key", key))}, nil
+	}
+	return nil, nil
+}
+
+// specWarnings returns the warnings for settings that are valid but likely not what the user intended.
+func specWarnings(redis *redisv1alpha1.Redis) admission.Warnings {
+	var warnings admission.Warnings
+	if redis.Spec.Mode == redisv1alpha1.ModeReplication && redis.Spec.Replicas != nil && *redis.Spec.Replicas < 2 {
+		warnings = append(warnings, "spec.replicas: replication mode with a single replica runs without a replica")
+	}
+	if redis.Spec.Service.ReadService != nil && redis.Spec.Mode != redisv1alpha1.ModeReplication {
+		warnings = append(warnings, "spec.service.readService: the read service is only created in replication mode")
+	}
+	return warnings
+}
+
+// invalid wraps the field errors into an Invalid status error, or returns nil without errors.
+func invalid(redis *redisv1alpha1.Redis, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(schema.GroupKind{Group: redisv1alpha1.GroupVersion.Group, Kind: "Redis"}, redis.Name, allErrs)
+}
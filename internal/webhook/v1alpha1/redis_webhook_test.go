@@ -0,0 +1,239 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+)
+
+var _ = Describe("Redis Webhook", func() {
+	var (
+		obj       *redisv1alpha1.Redis
+		oldObj    *redisv1alpha1.Redis
+		validator RedisCustomValidator
+		defaulter RedisCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		obj = newTestRedis("test-webhook")
+		oldObj = newTestRedis("test-webhook")
+		validator = RedisCustomValidator{Client: k8sClient}
+		Expect(validator).NotTo(BeNil(), "Expected validator to be initialized")
+		defaulter = RedisCustomDefaulter{}
+		Expect(defaulter).NotTo(BeNil(), "Expected defaulter to be initialized")
+	})
+
+	Context("When creating or updating Redis under Defaulting Webhook", func() {
+		It("Should materialize the image tag, probes and resources", func() {
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Image).To(Equal("bitnami/redis:" + redisv1alpha1.DefaultImageTag))
+			Expect(obj.Spec.ReadinessProbe.Exec.Command).To(Equal([]string{"redis-cli", "ping"}))
+			Expect(obj.Spec.LivenessProbe.TCPSocket.Port.IntValue()).To(Equal(6379))
+			Expect(obj.Spec.Resources).To(Equal(redisv1alpha1.DefaultResources()))
+		})
+
+		It("Should keep explicit images and probes", func() {
+			obj.Spec.Image = "registry.local:5000/redis@sha256:0123"
+			obj.Spec.LivenessProbe = &corev1.Probe{PeriodSeconds: 5}
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Image).To(Equal("registry.local:5000/redis@sha256:0123"))
+			Expect(obj.Spec.LivenessProbe).To(Equal(&corev1.Probe{PeriodSeconds: 5}))
+			Expect(redisv1alpha1.ImageWithTag("registry.local:5000/redis")).To(Equal("registry.local:5000/redis:" + redisv1alpha1.DefaultImageTag))
+		})
+
+		It("Should re-derive defaulted probes when the spec changes", func() {
+			Expect(defaulter.Default(ctx, oldObj)).To(Succeed())
+			raw, err := json.Marshal(oldObj)
+			Expect(err).NotTo(HaveOccurred())
+			updateCtx := admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Update,
+				OldObject: runtime.RawExtension{Raw: raw},
+			}})
+
+			obj = oldObj.DeepCopy()
+			obj.Spec.TLS = &redisv1alpha1.TLS{}
+			Expect(defaulter.Default(updateCtx, obj)).To(Succeed())
+			Expect(obj.Spec.ReadinessProbe.Exec.Command).To(ContainElement("--tls"))
+		})
+	})
+
+	Context("When creating or updating Redis under Validating Webhook", func() {
+		It("Should admit a valid spec", func() {
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny ports reserved for the metrics exporter", func() {
+			port := int32(redisv1alpha1.ExporterPort)
+			obj.Spec.Service.Port = &port
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.service.port"))
+		})
+
+		It("Should deny a cluster bus port beyond 65535", func() {
+			port := int32(60000)
+			obj.Spec.Mode = redisv1alpha1.ModeCluster
+			obj.Spec.Cluster = &redisv1alpha1.Cluster{Shards: 3}
+			obj.Spec.Port = &port
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("cluster bus port"))
+		})
+
+		It("Should deny env vars set by the operator", func() {
+			obj.Spec.Env = &[]corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}, {Name: "REDIS_PASSWORD", Value: "secret"}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.env[1].name"))
+		})
+
+		It("Should deny a read service named like the primary service", func() {
+			obj.Spec.Mode = redisv1alpha1.ModeReplication
+			obj.Spec.Service.ReadService = &redisv1alpha1.ReadService{Name: obj.Spec.Service.Name}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.service.readService.name"))
+		})
+
+		It("Should deny a rotation interval within the grace period", func() {
+			obj.Spec.PasswordRotation = &redisv1alpha1.PasswordRotation{
+				Interval:    &metav1.Duration{Duration: time.Minute},
+				GracePeriod: metav1.Duration{Duration: 10 * time.Minute},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		})
+
+		It("Should deny an invalid backup schedule", func() {
+			obj.Spec.Backup = &redisv1alpha1.ScheduledBackup{
+				Schedule:    "every night",
+				Destination: redisv1alpha1.BackupDestination{PVC: &redisv1alpha1.PVCDestination{ClaimName: "backups"}},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.backup.schedule"))
+
+			obj.Spec.Backup.Schedule = "@daily"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should warn about replication mode with a single replica", func() {
+			obj.Spec.Mode = redisv1alpha1.ModeReplication
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+
+		It("Should admit metadata updates of a spec that no longer validates", func() {
+			oldObj.Spec.Env = &[]corev1.EnvVar{{Name: "REDISCLI_AUTH", Value: "secret"}}
+			obj.Spec.Env = &[]corev1.EnvVar{{Name: "REDISCLI_AUTH", Value: "secret"}}
+			obj.Finalizers = []string{"redis.yazio.com/finalizer"}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+
+			port := int32(6380)
+			obj.Spec.Port = &port
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		})
+
+		It("Should deny changing to cluster mode", func() {
+			obj.Spec.Mode = redisv1alpha1.ModeCluster
+			obj.Spec.Cluster = &redisv1alpha1.Cluster{Shards: 3}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.mode"))
+		})
+
+		It("Should deny shrinking volumes but admit changing their storage class", func() {
+			class := "fast"
+			oldObj.Spec.Persistence = &redisv1alpha1.Persistence{Size: resource.MustParse("2Gi")}
+			obj.Spec.Persistence = &redisv1alpha1.Persistence{Size: resource.MustParse("1Gi"), StorageClassName: &class}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.persistence.size"))
+			Expect(err.Error()).NotTo(ContainSubstring("spec.persistence.storageClassName"))
+
+			obj.Spec.Persistence.Size = resource.MustParse("2Gi")
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should only admit switching to an existing password secret", func() {
+			obj.Spec.PasswordSecretName = "test-webhook-new-password"
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("spec.passwordSecretName"))
+
+			By("creating the new password secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: obj.Spec.PasswordSecretName, Namespace: obj.Namespace},
+				StringData: map[string]string{"password": "new-password"},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, secret)
+
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject invalid specs through the API server", func() {
+			obj.Spec.Env = &[]corev1.EnvVar{{Name: "REDISCLI_AUTH", Value: "secret"}}
+			err := k8sClient.Create(ctx, obj)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("REDISCLI_AUTH is set by the operator"))
+		})
+	})
+})
+
+func newTestRedis(name string) *redisv1alpha1.Redis {
+	replicas := int32(1)
+	port := int32(6379)
+	return &redisv1alpha1.Redis{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: redisv1alpha1.RedisSpec{
+			Replicas:           &replicas,
+			Image:              "bitnami/redis",
+			Port:               &port,
+			PasswordSecretName: name + "-password",
+			Service: redisv1alpha1.Service{
+				Name: name + "-service",
+				Type: string(corev1.ServiceTypeClusterIP),
+				Port: &port,
+			},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	redisv1beta1 "github.com/pehlicd/redis-operator/api/v1beta1"
+)
+
+// SetupRedisWebhookWithManager registers the conversion webhook for Redis in the manager.
+// Redis is stored as v1beta1, so the API server converts v1alpha1 objects through it.
+// Validation and defaulting are served for v1alpha1, which the API server converts v1beta1 requests to.
+func SetupRedisWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&redisv1beta1.Redis{}).
+		Complete()
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	redisv1alpha1 "github.com/pehlicd/redis-operator/api/v1alpha1"
+	redisv1beta1 "github.com/pehlicd/redis-operator/api/v1beta1"
+)
+
+var _ = Describe("Redis Webhook", func() {
+	Context("When converting Redis between versions", func() {
+		It("Should round-trip a v1alpha1 object", func() {
+			port := int32(6380)
+			env := []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+			src := &redisv1alpha1.Redis{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-conversion", Namespace: "default"},
+				Spec: redisv1alpha1.RedisSpec{
+					Image:              "bitnami/redis:7.4",
+					Port:               &port,
+					PasswordSecretName: "test-conversion-password",
+					Env:                &env,
+					Mode:               redisv1alpha1.ModeReplication,
+					Service: redisv1alpha1.Service{
+						Name:        "test-conversion-service",
+						Type:        string(corev1.ServiceTypeClusterIP),
+						Port:        &port,
+						ReadService: &redisv1alpha1.ReadService{Name: "test-conversion-read"},
+					},
+					Persistence: &redisv1alpha1.Persistence{
+						Size: resource.MustParse("1Gi"),
+						AOF:  &redisv1alpha1.AOF{Enabled: true, AppendFsync: "always"},
+					},
+				},
+				Status: redisv1alpha1.RedisStatus{
+					Primary: "test-conversion-0",
+					Cluster: &redisv1alpha1.ClusterStatus{Nodes: []redisv1alpha1.ClusterNodeStatus{{ID: "a", Role: "primary"}}},
+				},
+			}
+
+			hub := &redisv1beta1.Redis{}
+			Expect(src.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec.Port).To(Equal(int32(6380)))
+			Expect(hub.Spec.PasswordSecret).To(Equal(redisv1beta1.PasswordSecret{Name: "test-conversion-password", Key: "password"}))
+			Expect(hub.Spec.Env).To(Equal(env))
+			Expect(hub.Spec.Service.ReadService.Port).To(BeZero())
+
+			dst := &redisv1alpha1.Redis{}
+			Expect(dst.ConvertFrom(hub)).To(Succeed())
+			Expect(dst).To(Equal(src))
+		})
+
+		It("Should keep a custom password key through v1alpha1", func() {
+			src := &redisv1beta1.Redis{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-conversion", Namespace: "default"},
+				Spec: redisv1beta1.RedisSpec{
+					Image:          "bitnami/redis:7.4",
+					Port:           6379,
+					PasswordSecret: redisv1beta1.PasswordSecret{Name: "shared", Key: "redis-password"},
+					Service:        redisv1beta1.Service{Name: "test-conversion-service", Type: corev1.ServiceTypeClusterIP, Port: 6379},
+				},
+			}
+
+			spoke := &redisv1alpha1.Redis{}
+			Expect(spoke.ConvertFrom(src)).To(Succeed())
+			Expect(spoke.Annotations).To(HaveKeyWithValue(redisv1alpha1.PasswordSecretKeyAnnotation, "redis-password"))
+			Expect(redisv1alpha1.PasswordSecretKey(spoke)).To(Equal("redis-password"))
+
+			dst := &redisv1beta1.Redis{}
+			Expect(spoke.ConvertTo(dst)).To(Succeed())
+			Expect(dst).To(Equal(src))
+		})
+
+		It("Should serve objects created as v1alpha1 as v1beta1", func() {
+			port := int32(6379)
+			obj := &redisv1alpha1.Redis{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-conversion", Namespace: "default"},
+				Spec: redisv1alpha1.RedisSpec{
+					Image:              "bitnami/redis:7.4",
+					Port:               &port,
+					PasswordSecretName: "test-conversion-password",
+					Service:            redisv1alpha1.Service{Name: "test-conversion-service", Type: "ClusterIP", Port: &port},
+				},
+			}
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, obj)
+
+			stored := &redisv1beta1.Redis{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: obj.Name, Namespace: obj.Namespace}, stored)).To(Succeed())
+			Expect(stored.Spec.PasswordSecret.Name).To(Equal("test-conversion-password"))
+			Expect(stored.Spec.Service.Port).To(Equal(int32(6379)))
+		})
+	})
+})
@@ -256,8 +256,71 @@ var _ = Describe("Manager", Ordered, func() {
 			))
 		})
 
+		It("should provisioned cert-manager", func() {
+			By("validating that cert-manager has the certificate Secret")
+			verifyCertManager := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get", "secrets", "webhook-server-cert", "-n", namespace)
+				_, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			Eventually(verifyCertManager).Should(Succeed())
+		})
+
+		It("should have CA injection for mutating webhooks", func() {
+			By("checking CA injection for mutating webhooks")
+			verifyCAInjection := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get",
+					"mutatingwebhookconfigurations.admissionregistration.k8s.io",
+					"redis-operator-mutating-webhook-configuration",
+					"-o", "go-template={{ range .webhooks }}{{ .clientConfig.caBundle }}{{ end }}")
+				mwhOutput, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(mwhOutput)).To(BeNumerically(">", 10))
+			}
+			Eventually(verifyCAInjection).Should(Succeed())
+		})
+
+		It("should have CA injection for validating webhooks", func() {
+			By("checking CA injection for validating webhooks")
+			verifyCAInjection := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get",
+					"validatingwebhookconfigurations.admissionregistration.k8s.io",
+					"redis-operator-validating-webhook-configuration",
+					"-o", "go-template={{ range .webhooks }}{{ .clientConfig.caBundle }}{{ end }}")
+				vwhOutput, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(len(vwhOutput)).To(BeNumerically(">", 10))
+			}
+			Eventually(verifyCAInjection).Should(Succeed())
+		})
+
 		// +kubebuilder:scaffold:e2e-webhooks-checks
 
+		It("should back up a Redis to the MinIO stand-in", func() {
+			By("deploying MinIO, a Redis instance and a RedisBackup")
+			for _, sample := range []string{"minio.yaml", "redis_v1alpha1_redis.yaml", "redis_v1alpha1_redisbackup.yaml"} {
+				cmd := exec.Command("kubectl", "apply", "-n", "default", "-f", filepath.Join("config", "samples", sample))
+				_, err := utils.Run(cmd)
+				Expect(err).NotTo(HaveOccurred(), "Failed to apply %s", sample)
+			}
+			DeferCleanup(func() {
+				for _, sample := range []string{"redis_v1alpha1_redisbackup.yaml", "redis_v1alpha1_redis.yaml", "minio.yaml"} {
+					cmd := exec.Command("kubectl", "delete", "--ignore-not-found", "-n", "default", "-f", filepath.Join("config", "samples", sample))
+					_, _ = utils.Run(cmd)
+				}
+			})
+
+			By("waiting for the backup to complete")
+			verifyBackup := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get", "redisbackup", "redisbackup-sample", "-n", "default",
+					"-o", "jsonpath={.status.phase} {.status.checksum}")
+				output, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(output).To(MatchRegexp(`^Completed sha256:[0-9a-f]{64}$`))
+			}
+			Eventually(verifyBackup, 5*time.Minute).Should(Succeed())
+		})
+
 		// TODO: Customize the e2e test suite with scenarios specific to your project.
 		// Consider applying sample/CR(s) and check their status and/or verifying
 		// the reconciliation by using the metrics, i.e.: